@@ -5,31 +5,39 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"time"
 
 	oway "github.com/Oway-Inc/oway-sdk/packages/go"
 )
 
 func main() {
+	ctx := context.Background()
+
+	// Per-company API keys, keyed by your internal tenant IDs:
+	//   acme: oway_sk_acme_123
+	//   widgets: oway_sk_widgets_456
+	companies, err := oway.NewCompanyRegistry(ctx, oway.FileKeys("company-keys.yaml"))
+	if err != nil {
+		panic(err)
+	}
+	// Pick up added or rotated keys without restarting
+	go companies.AutoReload(ctx, time.Minute, func(err error) {
+		fmt.Printf("reload company keys: %v\n", err)
+	})
+
 	// M2M credentials from Sales Engineering
 	client, _ := oway.New(oway.Config{
 		ClientID:     os.Getenv("OWAY_M2M_CLIENT_ID"),
 		ClientSecret: os.Getenv("OWAY_M2M_CLIENT_SECRET"),
 		APIKey:       "oway_sk_default", // Optional default
+		Companies:    companies,
 	})
 
-	ctx := context.Background()
-
-	// Per-company API keys
-	keys := map[string]string{
-		"acme": "oway_sk_acme_123",
-		"widgets": "oway_sk_widgets_456",
-	}
-
 	// Quote for ACME (uses their API key)
-	quoteA, _ := client.RequestQuoteForCompany(ctx, &oway.QuoteRequest{}, keys["acme"])
+	quoteA, _ := client.RequestQuote(oway.WithCompany(ctx, "acme"), &oway.QuoteRequest{})
 	fmt.Printf("ACME: %s\n", quoteA.Id)
 
 	// Quote for Widgets (uses their API key)
-	quoteB, _ := client.RequestQuoteForCompany(ctx, &oway.QuoteRequest{}, keys["widgets"])
+	quoteB, _ := client.RequestQuote(oway.WithCompany(ctx, "widgets"), &oway.QuoteRequest{})
 	fmt.Printf("Widgets: %s\n", quoteB.Id)
 }
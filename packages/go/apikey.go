@@ -0,0 +1,87 @@
+package oway
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// API key prefixes required by the shipper and carrier endpoints
+const (
+	shipperKeyPrefix = "oway_sk_"
+	carrierKeyPrefix = "oway_ck_"
+)
+
+// ErrAPIKeyType is returned, before anything is sent, when the API key for a
+// call is not the kind its endpoint requires: shipper endpoints need an
+// oway_sk_ key and carrier endpoints an oway_ck_ key
+var ErrAPIKeyType = errors.New("wrong API key type")
+
+// requiredKeyPrefix returns the API key prefix an operation requires, or ""
+// if the operation is not a shipper or carrier endpoint
+func requiredKeyPrefix(operation string) string {
+	switch {
+	case isCarrierOperation(operation):
+		return carrierKeyPrefix
+	case operation == "" || operation == OperationGetToken:
+		return ""
+	default:
+		return shipperKeyPrefix
+	}
+}
+
+// defaultAPIKey returns the configured default key for an operation
+func (c *Client) defaultAPIKey(operation string) string {
+	switch requiredKeyPrefix(operation) {
+	case shipperKeyPrefix:
+		if c.config.ShipperAPIKey != "" {
+			return c.config.ShipperAPIKey
+		}
+	case carrierKeyPrefix:
+		if c.config.CarrierAPIKey != "" {
+			return c.config.CarrierAPIKey
+		}
+	}
+	return c.config.APIKey
+}
+
+// validateAPIKey checks that apiKey is the kind the operation requires. A
+// missing key is left for the API to reject.
+func validateAPIKey(operation, apiKey string) error {
+	prefix := requiredKeyPrefix(operation)
+	if prefix == "" || apiKey == "" || strings.HasPrefix(apiKey, prefix) {
+		return nil
+	}
+
+	kind := "shipper"
+	if prefix == carrierKeyPrefix {
+		kind = "carrier"
+	}
+	return fmt.Errorf("%w: %s requires a %s API key (%s...), got %s", ErrAPIKeyType, operation, kind, prefix, redactAPIKey(apiKey))
+}
+
+// redactAPIKey masks an API key for logs and errors, keeping its type prefix
+// and last four characters (e.g. "oway_sk_live_****f00d")
+func redactAPIKey(apiKey string) string {
+	if apiKey == "" {
+		return ""
+	}
+
+	prefix := ""
+	for _, p := range []string{shipperKeyPrefix, carrierKeyPrefix} {
+		if strings.HasPrefix(apiKey, p) {
+			prefix = p
+			for _, env := range []string{"test_", "live_"} {
+				if strings.HasPrefix(apiKey[len(p):], env) {
+					prefix += env
+				}
+			}
+		}
+	}
+
+	rest := apiKey[len(prefix):]
+	if len(rest) <= 8 {
+		return prefix + "****"
+	}
+	return prefix + "****" + rest[len(rest)-4:]
+}
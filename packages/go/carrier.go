@@ -0,0 +1,102 @@
+package oway
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+// Carrier API methods require a carrier API key (oway_ck_...), either as
+// Config.CarrierAPIKey or per request via client.ForCompany(key).
+
+// GetCarrierApiConfig retrieves the API configuration for a carrier
+func (c *Client) GetCarrierApiConfig(ctx context.Context, carrierID string) (*CarrierConfig, error) {
+	res, err := c.client.GetCarrierApiConfigWithResponse(ctx, carrierID)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode() != http.StatusOK {
+		return nil, newAPIError("get carrier api config", res.HTTPResponse, res.Body, res.JSON401, res.JSON403, res.JSON404, res.JSON500)
+	}
+	if res.JSON200 == nil {
+		return nil, fmt.Errorf("unexpected empty response body")
+	}
+	return res.JSON200, nil
+}
+
+// GetCarrierApiConfigForCompany retrieves the API configuration using a specific carrier API key
+//
+// Deprecated: use c.ForCompany(companyAPIKey).GetCarrierApiConfig
+func (c *Client) GetCarrierApiConfigForCompany(ctx context.Context, carrierID string, companyAPIKey string) (*CarrierConfig, error) {
+	ctx = WithCompanyAPIKey(ctx, companyAPIKey)
+	return c.GetCarrierApiConfig(ctx, carrierID)
+}
+
+// AddGpsData submits GPS data points for carrier vehicles and returns the number added
+func (c *Client) AddGpsData(ctx context.Context, carrierID string, points []GpsData) (int32, error) {
+	res, err := c.client.AddGpsDataWithResponse(ctx, carrierID, points)
+	if err != nil {
+		return 0, err
+	}
+	if res.StatusCode() != http.StatusOK {
+		return 0, newAPIError("add gps data", res.HTTPResponse, res.Body, res.JSON400, res.JSON401, res.JSON403, res.JSON404, res.JSON500)
+	}
+	if res.JSON200 == nil {
+		return 0, fmt.Errorf("unexpected empty response body")
+	}
+	return *res.JSON200, nil
+}
+
+// AddGpsDataForCompany submits GPS data using a specific carrier API key
+//
+// Deprecated: use c.ForCompany(companyAPIKey).AddGpsData
+func (c *Client) AddGpsDataForCompany(ctx context.Context, carrierID string, points []GpsData, companyAPIKey string) (int32, error) {
+	ctx = WithCompanyAPIKey(ctx, companyAPIKey)
+	return c.AddGpsData(ctx, carrierID, points)
+}
+
+// GetJobs retrieves jobs (offers with order data) for a carrier
+func (c *Client) GetJobs(ctx context.Context, carrierID string, params *GetJobsParams) ([]Job, error) {
+	res, err := c.client.GetJobsWithResponse(ctx, carrierID, params)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode() != http.StatusOK {
+		return nil, newAPIError("get jobs", res.HTTPResponse, res.Body, res.JSON401, res.JSON403, res.JSON404, res.JSON500)
+	}
+	if res.JSON200 == nil {
+		return nil, fmt.Errorf("unexpected empty response body")
+	}
+	return *res.JSON200, nil
+}
+
+// GetJobsForCompany retrieves jobs using a specific carrier API key
+//
+// Deprecated: use c.ForCompany(companyAPIKey).GetJobs
+func (c *Client) GetJobsForCompany(ctx context.Context, carrierID string, params *GetJobsParams, companyAPIKey string) ([]Job, error) {
+	ctx = WithCompanyAPIKey(ctx, companyAPIKey)
+	return c.GetJobs(ctx, carrierID, params)
+}
+
+// AddTrips submits trips for carrier vehicles and returns the number added
+func (c *Client) AddTrips(ctx context.Context, carrierID string, trips []TripRequest) (int32, error) {
+	res, err := c.client.AddTripsWithResponse(ctx, carrierID, trips)
+	if err != nil {
+		return 0, err
+	}
+	if res.StatusCode() != http.StatusOK {
+		return 0, newAPIError("add trips", res.HTTPResponse, res.Body, res.JSON400, res.JSON401, res.JSON403, res.JSON404, res.JSON500)
+	}
+	if res.JSON200 == nil {
+		return 0, fmt.Errorf("unexpected empty response body")
+	}
+	return *res.JSON200, nil
+}
+
+// AddTripsForCompany submits trips using a specific carrier API key
+//
+// Deprecated: use c.ForCompany(companyAPIKey).AddTrips
+func (c *Client) AddTripsForCompany(ctx context.Context, carrierID string, trips []TripRequest, companyAPIKey string) (int32, error) {
+	ctx = WithCompanyAPIKey(ctx, companyAPIKey)
+	return c.AddTrips(ctx, carrierID, trips)
+}
@@ -0,0 +1,287 @@
+// Package cassette records Oway API interactions to a file and replays them
+// deterministically, for tests that should not depend on the sandbox.
+//
+// Plug a Recorder into oway.Config.HTTPClient:
+//
+//	rec, err := cassette.New("testdata/booking.json", cassette.ModeReplay)
+//	client, err := oway.New(oway.Config{..., HTTPClient: rec.HTTPClient()})
+//	defer rec.Stop()
+//
+// Credentials (Authorization, x-oway-api-key, clientSecret and access tokens)
+// are redacted before anything is written to disk.
+package cassette
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Mode selects whether a Recorder records or replays
+type Mode int
+
+const (
+	// ModeRecord sends requests to the real API and records them
+	ModeRecord Mode = iota
+
+	// ModeReplay serves recorded responses and never touches the network
+	ModeReplay
+)
+
+// Redacted replaces secret values in recorded interactions
+const Redacted = "REDACTED"
+
+// ErrUnmatched is returned in replay mode for requests with no recorded interaction
+var ErrUnmatched = errors.New("cassette: no recorded interaction matches request")
+
+var redactedHeaders = []string{"Authorization", "x-oway-api-key"}
+
+var redactedFields = []string{"clientSecret", "accessToken"}
+
+// Cassette is the on-disk format: interactions in the order they were recorded
+type Cassette struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Interaction is one recorded request/response pair
+type Interaction struct {
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+}
+
+// Request is a recorded request. URL holds the path and query only, so a
+// cassette recorded against one environment replays against any BaseURL.
+type Request struct {
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+	Header http.Header `json:"header,omitempty"`
+	Body   string      `json:"body,omitempty"`
+}
+
+// Response is a recorded response
+type Response struct {
+	StatusCode int         `json:"statusCode"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       string      `json:"body,omitempty"`
+}
+
+// Recorder is an http.RoundTripper that records or replays interactions
+type Recorder struct {
+	// Transport sends requests in ModeRecord (default: http.DefaultTransport)
+	Transport http.RoundTripper
+
+	path     string
+	mode     Mode
+	mu       sync.Mutex
+	cassette Cassette
+	used     []bool
+}
+
+// New creates a Recorder for the cassette file at path. In ModeReplay the
+// file must exist; in ModeRecord it is written by Stop.
+func New(path string, mode Mode) (*Recorder, error) {
+	r := &Recorder{path: path, mode: mode}
+	if mode == ModeReplay {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("cassette: %w", err)
+		}
+		if err := json.Unmarshal(data, &r.cassette); err != nil {
+			return nil, fmt.Errorf("cassette: invalid cassette %s: %w", path, err)
+		}
+		r.used = make([]bool, len(r.cassette.Interactions))
+	}
+	return r, nil
+}
+
+// HTTPClient returns an *http.Client using the Recorder as its transport
+func (r *Recorder) HTTPClient() *http.Client {
+	return &http.Client{Transport: r}
+}
+
+// Stop writes the cassette file in ModeRecord; it is a no-op in ModeReplay
+func (r *Recorder) Stop() error {
+	if r.mode != ModeRecord {
+		return nil
+	}
+
+	r.mu.Lock()
+	data, err := json.MarshalIndent(r.cassette, "", "  ")
+	r.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("cassette: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(r.path), 0o755); err != nil {
+		return fmt.Errorf("cassette: %w", err)
+	}
+	return os.WriteFile(r.path, append(data, '\n'), 0o644)
+}
+
+// Interactions returns the interactions recorded or loaded so far
+func (r *Recorder) Interactions() []Interaction {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Interaction(nil), r.cassette.Interactions...)
+}
+
+// RoundTrip implements http.RoundTripper
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, req, err := readBody(req)
+	if err != nil {
+		return nil, err
+	}
+	recorded := Request{
+		Method: req.Method,
+		URL:    req.URL.RequestURI(),
+		Header: redactHeader(req.Header),
+		Body:   redactBody(body),
+	}
+
+	if r.mode == ModeReplay {
+		return r.replay(req, recorded)
+	}
+	return r.record(req, recorded)
+}
+
+func (r *Recorder) record(req *http.Request, recorded Request) (*http.Response, error) {
+	transport := r.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+
+	resp, err := transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	r.mu.Lock()
+	r.cassette.Interactions = append(r.cassette.Interactions, Interaction{
+		Request: recorded,
+		Response: Response{
+			StatusCode: resp.StatusCode,
+			Header:     redactHeader(resp.Header),
+			Body:       redactBody(body),
+		},
+	})
+	r.mu.Unlock()
+
+	return resp, nil
+}
+
+// replay serves the first unused interaction matching method, path and
+// normalized JSON body, so repeated identical requests (e.g. polling) get
+// their recorded responses in order
+func (r *Recorder) replay(req *http.Request, recorded Request) (*http.Response, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, interaction := range r.cassette.Interactions {
+		if r.used[i] || !matches(interaction.Request, recorded) {
+			continue
+		}
+		r.used[i] = true
+
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", interaction.Response.StatusCode, http.StatusText(interaction.Response.StatusCode)),
+			StatusCode:    interaction.Response.StatusCode,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        interaction.Response.Header.Clone(),
+			Body:          io.NopCloser(bytes.NewReader([]byte(interaction.Response.Body))),
+			ContentLength: int64(len(interaction.Response.Body)),
+			Request:       req,
+		}, nil
+	}
+
+	return nil, fmt.Errorf("%w: %s %s %s", ErrUnmatched, recorded.Method, recorded.URL, recorded.Body)
+}
+
+func matches(recorded, req Request) bool {
+	return recorded.Method == req.Method &&
+		recorded.URL == req.URL &&
+		normalizeJSON(recorded.Body) == normalizeJSON(req.Body)
+}
+
+// normalizeJSON re-encodes a JSON body so key order and whitespace don't
+// affect matching; non-JSON bodies are compared as-is
+func normalizeJSON(body string) string {
+	var v any
+	if json.Unmarshal([]byte(body), &v) != nil {
+		return body
+	}
+	normalized, _ := json.Marshal(v)
+	return string(normalized)
+}
+
+// readBody returns the body of req and a clone of req to send in its place,
+// so the caller's request is never modified. The body is read from a copy
+// made by GetBody when the request has one.
+func readBody(req *http.Request) ([]byte, *http.Request, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, req, nil
+	}
+	source := req.Body
+	if req.GetBody != nil {
+		req.Body.Close()
+		var err error
+		if source, err = req.GetBody(); err != nil {
+			return nil, nil, err
+		}
+	}
+	body, err := io.ReadAll(source)
+	source.Close()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	clone := req.Clone(req.Context())
+	clone.Body = io.NopCloser(bytes.NewReader(body))
+	clone.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(body)), nil
+	}
+	return body, clone, nil
+}
+
+func redactHeader(header http.Header) http.Header {
+	redacted := header.Clone()
+	for _, name := range redactedHeaders {
+		if redacted.Get(name) != "" {
+			redacted.Set(name, Redacted)
+		}
+	}
+	return redacted
+}
+
+// redactBody replaces secret fields in a JSON object body
+func redactBody(body []byte) string {
+	var object map[string]any
+	if json.Unmarshal(body, &object) != nil {
+		return string(body)
+	}
+
+	changed := false
+	for _, field := range redactedFields {
+		if _, ok := object[field]; ok {
+			object[field] = Redacted
+			changed = true
+		}
+	}
+	if !changed {
+		return string(body)
+	}
+	redacted, _ := json.Marshal(object)
+	return string(redacted)
+}
@@ -0,0 +1,134 @@
+package cassette
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	oway "github.com/Oway-Inc/oway-sdk/packages/go"
+	"github.com/Oway-Inc/oway-sdk/packages/go/owaytest"
+)
+
+func TestRecordAndReplay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "quote.json")
+	ctx := context.Background()
+	request := &oway.QuoteRequest{
+		OrderComponents: []oway.OrderComponent{{PalletCount: 1, PoundsWeight: 400, PalletDimensions: []int32{48, 40, 48}}},
+	}
+
+	server := owaytest.NewServer()
+	config := server.Config()
+
+	rec, err := New(path, ModeRecord)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config.HTTPClient = rec.HTTPClient()
+	client, err := oway.New(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recorded, err := client.RequestQuote(ctx, request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := rec.Stop(); err != nil {
+		t.Fatal(err)
+	}
+	server.Close()
+
+	t.Run("should redact secrets", func(t *testing.T) {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, secret := range []string{owaytest.ClientSecret, owaytest.ShipperAPIKey, "owaytest_token_"} {
+			if strings.Contains(string(data), secret) {
+				t.Errorf("Cassette contains secret %q", secret)
+			}
+		}
+	})
+
+	t.Run("should replay without network", func(t *testing.T) {
+		rec, err := New(path, ModeReplay)
+		if err != nil {
+			t.Fatal(err)
+		}
+		config.HTTPClient = rec.HTTPClient()
+		client, err := oway.New(config)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		replayed, err := client.RequestQuote(ctx, request)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if *replayed.Id != *recorded.Id || *replayed.QuotedPriceInCents != *recorded.QuotedPriceInCents {
+			t.Errorf("Replayed quote %+v does not match recorded %+v", replayed, recorded)
+		}
+
+		_, err = client.TrackShipment(ctx, "T0001")
+		if !errors.Is(err, ErrUnmatched) {
+			t.Errorf("Expected ErrUnmatched, got %v", err)
+		}
+	})
+}
+
+func TestNormalizeJSON(t *testing.T) {
+	a := `{"b": 1, "a": {"y": true, "x": [1, 2]}}`
+	b := `{"a":{"x":[1,2],"y":true},"b":1}`
+	if normalizeJSON(a) != normalizeJSON(b) {
+		t.Errorf("Expected equivalent JSON bodies to match")
+	}
+}
+
+func TestRoundTripDoesNotModifyRequest(t *testing.T) {
+	var sent string
+	rec, err := New(filepath.Join(t.TempDir(), "body.json"), ModeRecord)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec.Transport = oway.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		body, err := io.ReadAll(req.Body)
+		if err != nil {
+			return nil, err
+		}
+		sent = string(body)
+		return &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Body: http.NoBody, Request: req}, nil
+	})
+
+	for _, tc := range []struct {
+		name    string
+		getBody bool
+	}{
+		{"should leave the caller's body in place", false},
+		{"should read the body from GetBody", true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodPost, "https://api.example.com/v1/shipper/quote", strings.NewReader(`{"a":1}`))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !tc.getBody {
+				req.GetBody = nil
+			}
+			body := req.Body
+
+			if _, err := rec.RoundTrip(req); err != nil {
+				t.Fatal(err)
+			}
+			if req.Body != body {
+				t.Error("Expected RoundTrip to leave req.Body unchanged")
+			}
+			if sent != `{"a":1}` {
+				t.Errorf("Expected the body to be sent, got %q", sent)
+			}
+		})
+	}
+}
@@ -0,0 +1,267 @@
+package oway
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen matches the error returned, without contacting the API,
+// while the circuit breaker for an endpoint is open
+var ErrCircuitOpen = errors.New("circuit breaker open")
+
+// CircuitOpenError is returned instead of sending a call while the circuit
+// breaker for its endpoint is open. It matches ErrCircuitOpen.
+type CircuitOpenError struct {
+	// Operation is the endpoint whose circuit is open (e.g. OperationTrackShipment)
+	Operation string
+
+	// Until is when the breaker lets probe requests through again
+	Until time.Time
+}
+
+// Error implements the error interface
+func (e *CircuitOpenError) Error() string {
+	return fmt.Sprintf("%s: circuit breaker open until %s", e.Operation, e.Until.Format(time.RFC3339))
+}
+
+// Is reports whether target is ErrCircuitOpen
+func (e *CircuitOpenError) Is(target error) bool {
+	return target == ErrCircuitOpen
+}
+
+// CircuitState is the state of a circuit breaker
+type CircuitState string
+
+// Circuit breaker states
+const (
+	// CircuitClosed lets all calls through
+	CircuitClosed CircuitState = "closed"
+	// CircuitOpen fails calls fast with ErrCircuitOpen
+	CircuitOpen CircuitState = "open"
+	// CircuitHalfOpen lets a few probe calls through to test recovery
+	CircuitHalfOpen CircuitState = "half-open"
+)
+
+// CircuitBreaker configures a circuit breaker per endpoint. A breaker opens
+// when server errors (5xx) and transport errors (timeouts, refused
+// connections, ...) make up FailureRatio of at least MinRequests attempts
+// within Window. After OpenTimeout it half-opens and lets HalfOpenProbes
+// calls through: if they all succeed it closes, if any fails it opens again.
+// Attempts cancelled by the caller are not counted.
+type CircuitBreaker struct {
+	// FailureRatio is the fraction of failed attempts that opens the circuit (default: 0.5)
+	FailureRatio float64
+
+	// MinRequests is the number of attempts in Window before the ratio is evaluated (default: 10)
+	MinRequests int
+
+	// Window is the period over which attempts are counted (default: 1 minute)
+	Window time.Duration
+
+	// OpenTimeout is how long the circuit stays open before probing (default: 30 seconds)
+	OpenTimeout time.Duration
+
+	// HalfOpenProbes is the number of probe calls allowed while half-open (default: 1)
+	HalfOpenProbes int
+
+	// OnStateChange is called on every state transition, e.g. to alert
+	OnStateChange func(operation string, from, to CircuitState)
+}
+
+// circuitBreakers holds one breaker per operation
+type circuitBreakers struct {
+	config CircuitBreaker
+
+	mu       sync.Mutex
+	breakers map[string]*breaker
+}
+
+type breaker struct {
+	state       CircuitState
+	windowStart time.Time
+	requests    int
+	failures    int
+	openUntil   time.Time
+	probes      int
+	successes   int
+	generation  int
+}
+
+func newCircuitBreakers(config CircuitBreaker) *circuitBreakers {
+	if config.FailureRatio <= 0 || config.FailureRatio > 1 {
+		config.FailureRatio = 0.5
+	}
+	if config.MinRequests <= 0 {
+		config.MinRequests = 10
+	}
+	if config.Window <= 0 {
+		config.Window = time.Minute
+	}
+	if config.OpenTimeout <= 0 {
+		config.OpenTimeout = 30 * time.Second
+	}
+	if config.HalfOpenProbes <= 0 {
+		config.HalfOpenProbes = 1
+	}
+	return &circuitBreakers{config: config, breakers: make(map[string]*breaker)}
+}
+
+// attemptOutcome is how an attempt counts towards its circuit breaker
+type attemptOutcome int
+
+const (
+	// attemptSucceeded counts as a success
+	attemptSucceeded attemptOutcome = iota
+	// attemptFailed counts as a failure
+	attemptFailed
+	// attemptCanceled counts as neither, and frees its half-open probe slot
+	attemptCanceled
+)
+
+// allow reports whether an attempt for operation may be sent. If so, the
+// caller must pass the attempt's outcome to done.
+func (cb *circuitBreakers) allow(operation string) (done func(outcome attemptOutcome), err error) {
+	cb.mu.Lock()
+	b, ok := cb.breakers[operation]
+	if !ok {
+		b = &breaker{state: CircuitClosed, windowStart: time.Now()}
+		cb.breakers[operation] = b
+	}
+
+	halfOpened := false
+	if b.state == CircuitOpen && !time.Now().Before(b.openUntil) {
+		b.transition(CircuitHalfOpen)
+		halfOpened = true
+	}
+
+	switch b.state {
+	case CircuitOpen:
+		cb.mu.Unlock()
+		return nil, &CircuitOpenError{Operation: operation, Until: b.openUntil}
+	case CircuitHalfOpen:
+		if b.probes >= cb.config.HalfOpenProbes {
+			cb.mu.Unlock()
+			return nil, &CircuitOpenError{Operation: operation, Until: b.openUntil}
+		}
+		b.probes++
+	}
+	generation := b.generation
+	cb.mu.Unlock()
+
+	if halfOpened {
+		cb.notify(operation, CircuitOpen, CircuitHalfOpen)
+	}
+	return func(outcome attemptOutcome) { cb.record(operation, b, generation, outcome) }, nil
+}
+
+// record counts an attempt's outcome and moves the breaker between states.
+// Outcomes of attempts allowed before the last transition are ignored.
+func (cb *circuitBreakers) record(operation string, b *breaker, generation int, outcome attemptOutcome) {
+	cb.mu.Lock()
+	if b.generation != generation {
+		cb.mu.Unlock()
+		return
+	}
+	if outcome == attemptCanceled {
+		if b.state == CircuitHalfOpen {
+			b.probes--
+		}
+		cb.mu.Unlock()
+		return
+	}
+	failed := outcome == attemptFailed
+	from := b.state
+	now := time.Now()
+
+	switch b.state {
+	case CircuitClosed:
+		if now.Sub(b.windowStart) > cb.config.Window {
+			b.windowStart, b.requests, b.failures = now, 0, 0
+		}
+		b.requests++
+		if failed {
+			b.failures++
+		}
+		if b.requests >= cb.config.MinRequests && float64(b.failures) >= cb.config.FailureRatio*float64(b.requests) {
+			b.transition(CircuitOpen)
+			b.openUntil = now.Add(cb.config.OpenTimeout)
+		}
+	case CircuitHalfOpen:
+		b.successes++
+		switch {
+		case failed:
+			b.transition(CircuitOpen)
+			b.openUntil = now.Add(cb.config.OpenTimeout)
+		case b.successes >= cb.config.HalfOpenProbes:
+			b.transition(CircuitClosed)
+			b.windowStart = now
+		}
+	}
+
+	to := b.state
+	cb.mu.Unlock()
+	cb.notify(operation, from, to)
+}
+
+// transition moves the breaker to state and resets its counters; cb.mu must be held
+func (b *breaker) transition(state CircuitState) {
+	b.state = state
+	b.generation++
+	b.requests, b.failures, b.probes, b.successes = 0, 0, 0, 0
+}
+
+// notify calls OnStateChange for a transition; cb.mu must not be held
+func (cb *circuitBreakers) notify(operation string, from, to CircuitState) {
+	if from != to && cb.config.OnStateChange != nil {
+		cb.config.OnStateChange(operation, from, to)
+	}
+}
+
+// state returns the current state of the breaker for operation
+func (cb *circuitBreakers) state(operation string) CircuitState {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	if b, ok := cb.breakers[operation]; ok {
+		return b.state
+	}
+	return CircuitClosed
+}
+
+// CircuitState returns the state of the circuit breaker for an operation
+// (e.g. OperationTrackShipment). It is CircuitClosed when Config.CircuitBreaker
+// is nil.
+func (c *Client) CircuitState(operation string) CircuitState {
+	if c.breakers == nil {
+		return CircuitClosed
+	}
+	return c.breakers.state(operation)
+}
+
+// breakerKey names the endpoint a request is counted against
+func breakerKey(req *http.Request) string {
+	if operation := operationFor(req); operation != "" {
+		return operation
+	}
+	return req.Method + " " + req.URL.Path
+}
+
+// breakerOutcome classifies an attempt for the circuit breaker: a 5xx
+// response or a transport error (a timeout, refused or reset connection,
+// DNS failure, ...) is a failure, and cancellation by the caller counts as
+// neither success nor failure.
+func breakerOutcome(resp *http.Response, err error) attemptOutcome {
+	if err != nil {
+		if errors.Is(err, context.Canceled) {
+			return attemptCanceled
+		}
+		return attemptFailed
+	}
+	if (&Error{StatusCode: resp.StatusCode}).IsServerError() {
+		return attemptFailed
+	}
+	return attemptSucceeded
+}
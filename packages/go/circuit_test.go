@@ -0,0 +1,220 @@
+package oway
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	var trackCalls atomic.Int32
+	var healthy atomic.Bool
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/v1/auth/token" {
+			w.Write([]byte(`{"accessToken": "test_token", "expiresIn": 3600}`))
+			return
+		}
+		trackCalls.Add(1)
+		if !healthy.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"orderNumber": "ABC12", "orderStatus": "IN_TRANSIT"}`))
+	}))
+	defer server.Close()
+
+	var mu sync.Mutex
+	var transitions []CircuitState
+
+	client, err := New(Config{
+		ClientID:     "client_test",
+		ClientSecret: "secret_test",
+		BaseURL:      server.URL,
+		TokenURL:     server.URL + "/v1/auth/token",
+		Retry:        &RetryPolicy{MaxAttempts: 1},
+		CircuitBreaker: &CircuitBreaker{
+			MinRequests: 4,
+			OpenTimeout: 50 * time.Millisecond,
+			OnStateChange: func(operation string, from, to CircuitState) {
+				if operation != OperationTrackShipment {
+					t.Errorf("Unexpected operation %q", operation)
+				}
+				mu.Lock()
+				transitions = append(transitions, to)
+				mu.Unlock()
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	t.Run("should open after failures and fail fast", func(t *testing.T) {
+		for i := 0; i < 4; i++ {
+			client.TrackShipment(ctx, "ABC12")
+		}
+		if client.CircuitState(OperationTrackShipment) != CircuitOpen {
+			t.Fatalf("Expected open circuit, got %s", client.CircuitState(OperationTrackShipment))
+		}
+
+		_, err := client.TrackShipment(ctx, "ABC12")
+		var openErr *CircuitOpenError
+		if !errors.Is(err, ErrCircuitOpen) || !errors.As(err, &openErr) || openErr.Operation != OperationTrackShipment {
+			t.Errorf("Expected ErrCircuitOpen, got %v", err)
+		}
+		if trackCalls.Load() != 4 {
+			t.Errorf("Expected 4 calls to reach the server, got %d", trackCalls.Load())
+		}
+	})
+
+	t.Run("should leave other endpoints closed", func(t *testing.T) {
+		if client.CircuitState(OperationGetShipment) != CircuitClosed {
+			t.Errorf("Expected closed circuit for getShipment")
+		}
+	})
+
+	t.Run("should close after a successful probe", func(t *testing.T) {
+		healthy.Store(true)
+		time.Sleep(60 * time.Millisecond)
+		if _, err := client.TrackShipment(ctx, "ABC12"); err != nil {
+			t.Fatal(err)
+		}
+		if client.CircuitState(OperationTrackShipment) != CircuitClosed {
+			t.Errorf("Expected closed circuit, got %s", client.CircuitState(OperationTrackShipment))
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		want := []CircuitState{CircuitOpen, CircuitHalfOpen, CircuitClosed}
+		if len(transitions) != len(want) {
+			t.Fatalf("Expected transitions %v, got %v", want, transitions)
+		}
+		for i := range want {
+			if transitions[i] != want[i] {
+				t.Errorf("Expected transitions %v, got %v", want, transitions)
+			}
+		}
+	})
+}
+
+func TestCircuitBreakerCanceledProbe(t *testing.T) {
+	const (
+		failing = iota
+		hanging
+		healthy
+	)
+	var mode atomic.Int32
+	probing := make(chan struct{}, 1)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/v1/auth/token" {
+			w.Write([]byte(`{"accessToken": "test_token", "expiresIn": 3600}`))
+			return
+		}
+		switch mode.Load() {
+		case failing:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case hanging:
+			probing <- struct{}{}
+			<-r.Context().Done()
+		default:
+			w.Write([]byte(`{"orderNumber": "ABC12", "orderStatus": "IN_TRANSIT"}`))
+		}
+	}))
+	defer server.Close()
+
+	client, err := New(Config{
+		ClientID:     "client_test",
+		ClientSecret: "secret_test",
+		BaseURL:      server.URL,
+		TokenURL:     server.URL + "/v1/auth/token",
+		Retry:        &RetryPolicy{MaxAttempts: 1},
+		CircuitBreaker: &CircuitBreaker{
+			MinRequests: 2,
+			OpenTimeout: 20 * time.Millisecond,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 2; i++ {
+		client.TrackShipment(context.Background(), "ABC12")
+	}
+	if client.CircuitState(OperationTrackShipment) != CircuitOpen {
+		t.Fatalf("Expected open circuit, got %s", client.CircuitState(OperationTrackShipment))
+	}
+	time.Sleep(30 * time.Millisecond)
+
+	t.Run("should not close the circuit when the probe is cancelled", func(t *testing.T) {
+		mode.Store(hanging)
+		ctx, cancel := context.WithCancel(context.Background())
+		go func() {
+			<-probing
+			cancel()
+		}()
+		if _, err := client.TrackShipment(ctx, "ABC12"); !errors.Is(err, context.Canceled) {
+			t.Fatalf("Expected context.Canceled, got %v", err)
+		}
+		if client.CircuitState(OperationTrackShipment) != CircuitHalfOpen {
+			t.Errorf("Expected half-open circuit, got %s", client.CircuitState(OperationTrackShipment))
+		}
+	})
+
+	t.Run("should release the probe slot for the next call", func(t *testing.T) {
+		mode.Store(healthy)
+		if _, err := client.TrackShipment(context.Background(), "ABC12"); err != nil {
+			t.Fatalf("Expected the next probe to be sent, got %v", err)
+		}
+		if client.CircuitState(OperationTrackShipment) != CircuitClosed {
+			t.Errorf("Expected closed circuit, got %s", client.CircuitState(OperationTrackShipment))
+		}
+	})
+}
+
+func TestCircuitBreakerConnectionErrors(t *testing.T) {
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"accessToken": "test_token", "expiresIn": 3600}`))
+	}))
+	defer tokenServer.Close()
+
+	// A server that is closed at once leaves a port that refuses connections
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closed.Close()
+
+	client, err := New(Config{
+		ClientID:       "client_test",
+		ClientSecret:   "secret_test",
+		BaseURL:        closed.URL,
+		TokenURL:       tokenServer.URL,
+		Retry:          &RetryPolicy{MaxAttempts: 1},
+		CircuitBreaker: &CircuitBreaker{MinRequests: 3},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("should open when connections are refused", func(t *testing.T) {
+		for i := 0; i < 3; i++ {
+			if _, err := client.TrackShipment(context.Background(), "ABC12"); err == nil {
+				t.Fatal("Expected a connection error")
+			}
+		}
+		if client.CircuitState(OperationTrackShipment) != CircuitOpen {
+			t.Errorf("Expected open circuit, got %s", client.CircuitState(OperationTrackShipment))
+		}
+		if _, err := client.TrackShipment(context.Background(), "ABC12"); !errors.Is(err, ErrCircuitOpen) {
+			t.Errorf("Expected ErrCircuitOpen, got %v", err)
+		}
+	})
+}
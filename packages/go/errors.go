@@ -1,10 +1,19 @@
 package oway
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+
+	"github.com/Oway-Inc/oway-sdk/packages/go/client"
 )
 
+// ErrTokenRejected is returned when an API call is rejected with 401 even
+// after the cached access token was discarded and a new one fetched. The
+// returned error also wraps the *Error for the second response.
+var ErrTokenRejected = errors.New("access token rejected by the Oway API")
+
 // Error represents an error from the Oway API
 type Error struct {
 	// Message is the human-readable error message
@@ -18,6 +27,21 @@ type Error struct {
 
 	// RequestID is the request ID for debugging (from x-request-id header)
 	RequestID string
+
+	// ServerRequestID is the x-request-id echoed by the server, when it differs from RequestID
+	ServerRequestID string
+
+	// Title is the short problem summary from the RFC 9457 response body
+	Title string
+
+	// Detail is the occurrence-specific explanation from the RFC 9457 response body
+	Detail string
+
+	// Reason is the machine-readable reason code from the RFC 9457 response body
+	Reason string
+
+	// Type is the problem type URI from the RFC 9457 response body
+	Type string
 }
 
 // Error implements the error interface
@@ -63,6 +87,67 @@ func (e *Error) IsServerError() bool {
 	return e.StatusCode >= 500 && e.StatusCode < 600
 }
 
+// AuthError is returned when an M2M access token cannot be obtained. Code
+// and Description come from the TokenErrorResponse body when the token
+// endpoint returns one; Err holds the underlying cause for transport and
+// decoding failures.
+type AuthError struct {
+	// StatusCode is the HTTP status code from the token endpoint (0 if no response)
+	StatusCode int
+
+	// Code is the error code from the response body (e.g., "invalid_client")
+	Code string
+
+	// Description is the human-readable error description from the response body
+	Description string
+
+	// Err is the underlying error, if any
+	Err error
+}
+
+// Error implements the error interface
+func (e *AuthError) Error() string {
+	msg := "M2M token request failed"
+	if e.StatusCode != 0 {
+		msg = fmt.Sprintf("%s (status: %d)", msg, e.StatusCode)
+	}
+	switch {
+	case e.Code != "" && e.Description != "":
+		msg = fmt.Sprintf("%s: %s: %s", msg, e.Code, e.Description)
+	case e.Code != "" || e.Description != "":
+		msg = fmt.Sprintf("%s: %s%s", msg, e.Code, e.Description)
+	}
+	if e.Err != nil {
+		msg = fmt.Sprintf("%s: %v", msg, e.Err)
+	}
+	return msg
+}
+
+// Unwrap returns the underlying error
+func (e *AuthError) Unwrap() error {
+	return e.Err
+}
+
+// IsInvalidCredentials reports whether the token endpoint rejected the
+// ClientID/ClientSecret. Retrying will not help; fix the configuration.
+func (e *AuthError) IsInvalidCredentials() bool {
+	return e.StatusCode == http.StatusBadRequest || e.StatusCode == http.StatusUnauthorized || e.StatusCode == http.StatusForbidden
+}
+
+// IsServerError reports whether the token could not be obtained because of
+// a server, network or malformed-response failure rather than bad credentials
+func (e *AuthError) IsServerError() bool {
+	return !e.IsInvalidCredentials()
+}
+
+// IsRetryable determines if the token request may succeed if repeated
+func (e *AuthError) IsRetryable() bool {
+	if e.StatusCode == 0 {
+		return e.Err != nil
+	}
+	return (&Error{StatusCode: e.StatusCode}).IsRetryable()
+}
+
 // NewError creates a new Oway error
 func NewError(message, code string, statusCode int, requestID string) *Error {
 	return &Error{
@@ -72,3 +157,57 @@ func NewError(message, code string, statusCode int, requestID string) *Error {
 		RequestID:  requestID,
 	}
 }
+
+// newAPIError converts a non-2xx response into an *Error. The problem details
+// already decoded by the generated Parse*Response functions are preferred; if
+// none match the status code, the raw body is decoded as a ProblemDetail.
+func newAPIError(operation string, resp *http.Response, body []byte, problems ...*client.ProblemDetail) *Error {
+	e := &Error{}
+	if resp != nil {
+		e.StatusCode = resp.StatusCode
+		e.RequestID = sentRequestID(resp)
+		e.ServerRequestID = serverRequestID(resp)
+		if e.RequestID == "" {
+			e.RequestID, e.ServerRequestID = e.ServerRequestID, ""
+		}
+	}
+
+	var problem *client.ProblemDetail
+	for _, p := range problems {
+		if p != nil {
+			problem = p
+			break
+		}
+	}
+	if problem == nil && len(body) > 0 {
+		var p client.ProblemDetail
+		if json.Unmarshal(body, &p) == nil {
+			problem = &p
+		}
+	}
+
+	if problem != nil {
+		e.Title = stringValue(problem.Title)
+		e.Detail = stringValue(problem.Detail)
+		e.Reason = stringValue(problem.Reason)
+		e.Type = stringValue(problem.Type)
+	}
+
+	e.Code = e.Reason
+	switch {
+	case e.Detail != "":
+		e.Message = fmt.Sprintf("%s failed: %s", operation, e.Detail)
+	case e.Title != "":
+		e.Message = fmt.Sprintf("%s failed: %s", operation, e.Title)
+	default:
+		e.Message = fmt.Sprintf("%s failed: %s", operation, http.StatusText(e.StatusCode))
+	}
+	return e
+}
+
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -0,0 +1,19 @@
+//go:build !unix
+
+package oway
+
+import "os"
+
+// lockFile is a no-op where advisory locks are unavailable. Writes still
+// replace the file atomically, so readers never see a partial file.
+func lockFile(f *os.File, exclusive bool) error {
+	return nil
+}
+
+func tryLockFile(f *os.File) (bool, error) {
+	return true, nil
+}
+
+func unlockFile(f *os.File) error {
+	return nil
+}
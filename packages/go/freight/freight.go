@@ -0,0 +1,264 @@
+// Package freight estimates the volume, density and density-based NMFC
+// freight class of order components before quoting, and flags pallets that
+// exceed standard LTL pallet limits. The estimate follows the density scale
+// only; commodities with their own NMFC class may be rated differently.
+package freight
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+
+	"github.com/Oway-Inc/oway-sdk/packages/go/client"
+	"github.com/Oway-Inc/oway-sdk/packages/go/validate"
+)
+
+// LengthUnit is the unit of OrderComponent.PalletDimensions
+type LengthUnit string
+
+// Length units
+const (
+	Inches      LengthUnit = "in"
+	Centimeters LengthUnit = "cm"
+)
+
+// WeightUnit is the unit of OrderComponent.PoundsWeight
+type WeightUnit string
+
+// Weight units
+const (
+	Pounds    WeightUnit = "lb"
+	Kilograms WeightUnit = "kg"
+)
+
+// Conversion factors to inches and pounds
+const (
+	inchesPerCentimeter = 1 / 2.54
+	poundsPerKilogram   = 2.20462262
+	cubicInchesPerFoot  = 12 * 12 * 12
+)
+
+// PalletLimits are the largest pallet accepted without special handling.
+// Lengths are in inches and weights in pounds.
+type PalletLimits struct {
+	// MaxLength limits the longer side of the footprint
+	MaxLength float64
+
+	// MaxWidth limits the shorter side of the footprint
+	MaxWidth float64
+
+	// MaxHeight limits the height including the pallet
+	MaxHeight float64
+
+	// MaxWeight limits the weight of one pallet
+	MaxWeight float64
+}
+
+// StandardPalletLimits fit a 48x40 in pallet stacked up to 96 in and 2,500 lb
+var StandardPalletLimits = PalletLimits{MaxLength: 48, MaxWidth: 40, MaxHeight: 96, MaxWeight: 2500}
+
+// Options configures Calculate
+type Options struct {
+	// LengthUnit is the unit of PalletDimensions (default: Inches)
+	LengthUnit LengthUnit
+
+	// WeightUnit is the unit of PoundsWeight (default: Pounds)
+	WeightUnit WeightUnit
+
+	// Limits are checked against every pallet (default: StandardPalletLimits)
+	Limits *PalletLimits
+}
+
+// Class is an NMFC freight class, e.g. 70 or 92.5
+type Class float64
+
+// String formats the class as the API reports it (e.g. "92.5")
+func (c Class) String() string {
+	return strconv.FormatFloat(float64(c), 'f', -1, 64)
+}
+
+// densityScale is the NMFC density scale: the class of freight whose density
+// in lb/ft³ is at least minDensity, densest first
+var densityScale = []struct {
+	minDensity float64
+	class      Class
+}{
+	{50, 50},
+	{35, 55},
+	{30, 60},
+	{22.5, 65},
+	{15, 70},
+	{12, 85},
+	{10, 92.5},
+	{8, 100},
+	{6, 125},
+	{4, 175},
+	{2, 250},
+	{1, 300},
+	{0, 400},
+}
+
+// ClassForDensity returns the freight class for a density in lb/ft³
+func ClassForDensity(density float64) Class {
+	for _, tier := range densityScale {
+		if density >= tier.minDensity {
+			return tier.class
+		}
+	}
+	return densityScale[len(densityScale)-1].class
+}
+
+// Violation is a pallet measurement over its limit, in inches or pounds
+type Violation struct {
+	// Field is "length", "width", "height" or "weight"
+	Field string
+
+	// Value is the pallet's measurement
+	Value float64
+
+	// Limit is the limit it exceeds
+	Limit float64
+}
+
+// String describes the violation (e.g. "height 100 in exceeds 96 in")
+func (v Violation) String() string {
+	unit := "in"
+	if v.Field == "weight" {
+		unit = "lb"
+	}
+	return fmt.Sprintf("%s %g %s exceeds %g %s", v.Field, v.Value, unit, v.Limit, unit)
+}
+
+// Component is the estimate for one OrderComponent, in feet and pounds
+type Component struct {
+	// Pallets is the component's PalletCount
+	Pallets int
+
+	// CubicFeet is the volume of all pallets of the component
+	CubicFeet float64
+
+	// Pounds is the weight of all pallets of the component
+	Pounds float64
+
+	// Density is the weight per cubic foot (lb/ft³)
+	Density float64
+
+	// Class is the density-based freight class
+	Class Class
+
+	// Violations lists where each pallet exceeds the limits, if anywhere
+	Violations []Violation
+}
+
+// Estimate totals the components of a shipment. Density and Class cover all
+// components together, as reported by AvgPoundsPerCubicFoot and FreightClass.
+type Estimate struct {
+	Components []Component
+	CubicFeet  float64
+	Pounds     float64
+	Density    float64
+	Class      Class
+}
+
+// ExceedsLimits reports whether any component exceeds the pallet limits
+func (e *Estimate) ExceedsLimits() bool {
+	for _, c := range e.Components {
+		if len(c.Violations) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Calculate estimates volume, density and freight class for components. It
+// returns a *validate.ValidationError if a component is malformed.
+func Calculate(components []client.OrderComponent, opts Options) (*Estimate, error) {
+	lengthFactor, weightFactor, err := opts.factors()
+	if err != nil {
+		return nil, err
+	}
+	limits := StandardPalletLimits
+	if opts.Limits != nil {
+		limits = *opts.Limits
+	}
+
+	estimate := &Estimate{Components: make([]Component, 0, len(components))}
+	for i, oc := range components {
+		if err := validate.OrderComponent(oc); err != nil {
+			return nil, fmt.Errorf("orderComponents[%d]: %w", i, err)
+		}
+
+		height := float64(oc.PalletDimensions[0]) * lengthFactor
+		length := float64(oc.PalletDimensions[1]) * lengthFactor
+		width := float64(oc.PalletDimensions[2]) * lengthFactor
+		weight := float64(oc.PoundsWeight) * weightFactor
+		pallets := float64(oc.PalletCount)
+
+		c := Component{
+			Pallets:    int(oc.PalletCount),
+			CubicFeet:  height * length * width / cubicInchesPerFoot * pallets,
+			Pounds:     weight * pallets,
+			Violations: limits.check(height, length, width, weight),
+		}
+		c.Density = c.Pounds / c.CubicFeet
+		c.Class = ClassForDensity(c.Density)
+
+		estimate.Components = append(estimate.Components, c)
+		estimate.CubicFeet += c.CubicFeet
+		estimate.Pounds += c.Pounds
+	}
+	if estimate.CubicFeet > 0 {
+		estimate.Density = estimate.Pounds / estimate.CubicFeet
+		estimate.Class = ClassForDensity(estimate.Density)
+	}
+	return estimate, nil
+}
+
+// factors returns the conversions from the configured units to inches and pounds
+func (o Options) factors() (length, weight float64, err error) {
+	switch o.LengthUnit {
+	case "", Inches:
+		length = 1
+	case Centimeters:
+		length = inchesPerCentimeter
+	default:
+		return 0, 0, fmt.Errorf("unknown length unit %q", o.LengthUnit)
+	}
+	switch o.WeightUnit {
+	case "", Pounds:
+		weight = 1
+	case Kilograms:
+		weight = poundsPerKilogram
+	default:
+		return 0, 0, fmt.Errorf("unknown weight unit %q", o.WeightUnit)
+	}
+	return length, weight, nil
+}
+
+// check returns where a pallet exceeds the limits; zero limits are not checked
+func (l PalletLimits) check(height, length, width, weight float64) []Violation {
+	// The footprint may be loaded either way round
+	longer, shorter := max(length, width), min(length, width)
+
+	var violations []Violation
+	for _, m := range []struct {
+		field        string
+		value, limit float64
+	}{
+		{"length", longer, l.MaxLength},
+		{"width", shorter, l.MaxWidth},
+		{"height", height, l.MaxHeight},
+		{"weight", weight, l.MaxWeight},
+	} {
+		// Compared at 0.1 precision so 122 cm is not flagged against 48 in
+		if value := round(m.value); m.limit > 0 && value > m.limit {
+			violations = append(violations, Violation{Field: m.field, Value: value, Limit: m.limit})
+		}
+	}
+	return violations
+}
+
+// round trims unit-conversion noise to 0.1
+func round(v float64) float64 {
+	return math.Round(v*10) / 10
+}
@@ -0,0 +1,109 @@
+package freight
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/Oway-Inc/oway-sdk/packages/go/client"
+	"github.com/Oway-Inc/oway-sdk/packages/go/validate"
+)
+
+func approx(a, b float64) bool {
+	return math.Abs(a-b) < 0.01
+}
+
+func TestClassForDensity(t *testing.T) {
+	tests := []struct {
+		density float64
+		want    Class
+	}{
+		{0.5, 400},
+		{1, 300},
+		{5, 175},
+		{10, 92.5},
+		{14.99, 85},
+		{15, 70},
+		{30, 60},
+		{49.9, 55},
+		{80, 50},
+	}
+
+	for _, tt := range tests {
+		if got := ClassForDensity(tt.density); got != tt.want {
+			t.Errorf("ClassForDensity(%g): expected %s, got %s", tt.density, tt.want, got)
+		}
+	}
+
+	if Class(92.5).String() != "92.5" || Class(70).String() != "70" {
+		t.Error("Expected classes to format like the API's freightClass")
+	}
+}
+
+func TestCalculate(t *testing.T) {
+	t.Run("should compute volume, density and class", func(t *testing.T) {
+		// 48x40x48 in = 53.33 ft³ per pallet
+		estimate, err := Calculate([]client.OrderComponent{
+			{PalletCount: 2, PalletDimensions: []int32{48, 40, 48}, PoundsWeight: 500},
+			{PalletCount: 1, PalletDimensions: []int32{48, 40, 48}, PoundsWeight: 200},
+		}, Options{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		first := estimate.Components[0]
+		if !approx(first.CubicFeet, 106.67) || first.Pounds != 1000 || !approx(first.Density, 9.375) || first.Class != 100 {
+			t.Errorf("Unexpected component estimate %+v", first)
+		}
+		if !approx(estimate.CubicFeet, 160) || estimate.Pounds != 1200 || !approx(estimate.Density, 7.5) || estimate.Class != 125 {
+			t.Errorf("Unexpected total estimate %+v", estimate)
+		}
+		if estimate.ExceedsLimits() {
+			t.Errorf("Expected standard pallets within limits, got %+v", estimate.Components)
+		}
+	})
+
+	t.Run("should convert centimeters and kilograms", func(t *testing.T) {
+		metric, err := Calculate([]client.OrderComponent{
+			{PalletCount: 1, PalletDimensions: []int32{122, 101, 122}, PoundsWeight: 227},
+		}, Options{LengthUnit: Centimeters, WeightUnit: Kilograms})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !approx(metric.Pounds, 500.45) || math.Abs(metric.CubicFeet-53.33) > 0.5 || metric.Class != 100 || metric.ExceedsLimits() {
+			t.Errorf("Unexpected metric estimate %+v", metric)
+		}
+	})
+
+	t.Run("should flag pallets over the limits", func(t *testing.T) {
+		estimate, err := Calculate([]client.OrderComponent{
+			{PalletCount: 1, PalletDimensions: []int32{100, 40, 60}, PoundsWeight: 3000},
+		}, Options{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		violations := estimate.Components[0].Violations
+		if !estimate.ExceedsLimits() || len(violations) != 3 {
+			t.Fatalf("Expected length, height and weight violations, got %v", violations)
+		}
+		if violations[0].String() != "length 60 in exceeds 48 in" {
+			t.Errorf("Unexpected violation %s", violations[0])
+		}
+
+		relaxed, _ := Calculate([]client.OrderComponent{
+			{PalletCount: 1, PalletDimensions: []int32{100, 40, 60}, PoundsWeight: 3000},
+		}, Options{Limits: &PalletLimits{MaxHeight: 110}})
+		if relaxed.ExceedsLimits() {
+			t.Errorf("Expected custom limits to apply, got %v", relaxed.Components[0].Violations)
+		}
+	})
+
+	t.Run("should reject malformed components", func(t *testing.T) {
+		_, err := Calculate([]client.OrderComponent{{PalletCount: 1, PalletDimensions: []int32{48, 40}, PoundsWeight: 500}}, Options{})
+		if !errors.Is(err, validate.ErrInvalid) {
+			t.Errorf("Expected validate.ErrInvalid, got %v", err)
+		}
+		if _, err := Calculate(nil, Options{LengthUnit: "ft"}); err == nil {
+			t.Error("Expected an error for an unknown unit")
+		}
+	})
+}
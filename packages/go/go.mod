@@ -2,9 +2,10 @@ module github.com/Oway-Inc/oway-sdk/packages/go
 
 go 1.24.0
 
-require github.com/oapi-codegen/runtime v1.1.1
-
 require (
-	github.com/apapsch/go-jsonmerge/v2 v2.0.0 // indirect
-	github.com/google/uuid v1.5.0 // indirect
+	github.com/google/uuid v1.5.0
+	github.com/oapi-codegen/runtime v1.1.1
+	gopkg.in/yaml.v3 v3.0.1
 )
+
+require github.com/apapsch/go-jsonmerge/v2 v2.0.0 // indirect
@@ -0,0 +1,265 @@
+package oway
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrStreamerClosed is returned when adding points to a closed GpsStreamer
+var ErrStreamerClosed = errors.New("gps streamer is closed")
+
+// GpsStreamerConfig holds configuration for a GpsStreamer
+type GpsStreamerConfig struct {
+	// BatchSize is the number of points per carrier that triggers an upload (default: 100)
+	BatchSize int
+
+	// FlushInterval is the maximum time a point waits before upload (default: 5 seconds)
+	FlushInterval time.Duration
+
+	// DedupWindow is how long a (vehicleId, timestamp) pair is remembered to
+	// drop duplicate points, including across batches (default: 1 minute)
+	DedupWindow time.Duration
+
+	// QueueSize bounds the number of queued points; Add blocks when full (default: 10000)
+	QueueSize int
+
+	// MaxRetries is the number of times a failed batch is retried (default: 3).
+	// Uploads are not also retried under Config.Retry.
+	MaxRetries int
+
+	// RetryDelay is the delay before the first batch retry; it doubles per retry (default: 1 second)
+	RetryDelay time.Duration
+
+	// APIKey is the carrier API key (oway_ck_...) used for uploads
+	// (default: Config.CarrierAPIKey, or Config.APIKey if that is empty)
+	APIKey string
+
+	// OnError is called with a batch that could not be uploaded after all retries
+	OnError func(carrierID string, points []GpsData, err error)
+}
+
+// GpsStreamer batches GPS points from many goroutines and uploads them with
+// AddGpsData. Points are grouped per carrier, deduplicated by vehicle and
+// timestamp, and uploaded when a batch fills up or FlushInterval elapses.
+type GpsStreamer struct {
+	client  *Client
+	config  GpsStreamerConfig
+	queue   chan gpsPoint
+	mu      sync.Mutex
+	closed  bool
+	adding  sync.WaitGroup
+	closing chan struct{}
+	done    chan struct{}
+	ctx     context.Context
+	cancel  context.CancelFunc
+}
+
+type gpsPoint struct {
+	carrierID string
+	data      GpsData
+}
+
+// gpsCarrier is the pending batch and recently seen points for one carrier
+type gpsCarrier struct {
+	points []GpsData
+	seen   map[gpsPointKey]time.Time
+}
+
+type gpsPointKey struct {
+	vehicleID string
+	timestamp int64
+}
+
+// NewGpsStreamer creates a GpsStreamer and starts its upload loop. Call Close
+// to flush pending points and stop it.
+func (c *Client) NewGpsStreamer(config GpsStreamerConfig) *GpsStreamer {
+	if config.BatchSize <= 0 {
+		config.BatchSize = 100
+	}
+	if config.FlushInterval <= 0 {
+		config.FlushInterval = 5 * time.Second
+	}
+	if config.DedupWindow <= 0 {
+		config.DedupWindow = time.Minute
+	}
+	if config.QueueSize <= 0 {
+		config.QueueSize = 10000
+	}
+	if config.MaxRetries < 0 {
+		config.MaxRetries = 0
+	} else if config.MaxRetries == 0 {
+		config.MaxRetries = 3
+	}
+	if config.RetryDelay <= 0 {
+		config.RetryDelay = time.Second
+	}
+
+	ctx, cancel := context.WithCancel(withoutRetries(context.Background()))
+	if config.APIKey != "" {
+		ctx = WithCompanyAPIKey(ctx, config.APIKey)
+	}
+
+	s := &GpsStreamer{
+		client:  c,
+		config:  config,
+		queue:   make(chan gpsPoint, config.QueueSize),
+		closing: make(chan struct{}),
+		done:    make(chan struct{}),
+		ctx:     ctx,
+		cancel:  cancel,
+	}
+	go s.run()
+	return s
+}
+
+// Add queues a GPS point for upload. It blocks while the queue is full,
+// returning ctx.Err() if ctx is done first, or ErrStreamerClosed after Close.
+func (s *GpsStreamer) Add(ctx context.Context, carrierID string, point GpsData) error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return ErrStreamerClosed
+	}
+	// The upload loop waits for Adds in progress before its final drain
+	s.adding.Add(1)
+	s.mu.Unlock()
+	defer s.adding.Done()
+
+	select {
+	case s.queue <- gpsPoint{carrierID: carrierID, data: point}:
+		return nil
+	case <-s.closing:
+		return ErrStreamerClosed
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Close stops accepting points and uploads everything pending. If ctx is
+// done before the flush completes, in-flight uploads are cancelled and
+// ctx.Err() is returned.
+func (s *GpsStreamer) Close(ctx context.Context) error {
+	s.mu.Lock()
+	if !s.closed {
+		s.closed = true
+		close(s.closing)
+	}
+	s.mu.Unlock()
+
+	select {
+	case <-s.done:
+		s.cancel()
+		return nil
+	case <-ctx.Done():
+		s.cancel()
+		<-s.done
+		return ctx.Err()
+	}
+}
+
+func (s *GpsStreamer) run() {
+	defer close(s.done)
+
+	ticker := time.NewTicker(s.config.FlushInterval)
+	defer ticker.Stop()
+
+	carriers := make(map[string]*gpsCarrier)
+
+	for {
+		select {
+		case p := <-s.queue:
+			s.collect(carriers, p)
+		case <-ticker.C:
+			s.flushAll(carriers)
+			s.prune(carriers)
+		case <-s.closing:
+			// Adds in progress return promptly now that closing is closed;
+			// once they have, the queue holds every point accepted
+			s.adding.Wait()
+			for {
+				select {
+				case p := <-s.queue:
+					s.collect(carriers, p)
+				default:
+					s.flushAll(carriers)
+					return
+				}
+			}
+		}
+	}
+}
+
+func (s *GpsStreamer) collect(carriers map[string]*gpsCarrier, p gpsPoint) {
+	carrier, ok := carriers[p.carrierID]
+	if !ok {
+		carrier = &gpsCarrier{seen: make(map[gpsPointKey]time.Time)}
+		carriers[p.carrierID] = carrier
+	}
+
+	key := gpsPointKey{vehicleID: p.data.VehicleId, timestamp: p.data.Timestamp.UnixNano()}
+	if _, dup := carrier.seen[key]; dup {
+		return
+	}
+	carrier.seen[key] = time.Now()
+	carrier.points = append(carrier.points, p.data)
+
+	if len(carrier.points) >= s.config.BatchSize {
+		s.upload(p.carrierID, carrier.points)
+		carrier.points = nil
+	}
+}
+
+func (s *GpsStreamer) flushAll(carriers map[string]*gpsCarrier) {
+	for carrierID, carrier := range carriers {
+		if len(carrier.points) > 0 {
+			s.upload(carrierID, carrier.points)
+			carrier.points = nil
+		}
+	}
+}
+
+// prune forgets points older than DedupWindow and carriers with nothing pending
+func (s *GpsStreamer) prune(carriers map[string]*gpsCarrier) {
+	cutoff := time.Now().Add(-s.config.DedupWindow)
+	for carrierID, carrier := range carriers {
+		for key, added := range carrier.seen {
+			if added.Before(cutoff) {
+				delete(carrier.seen, key)
+			}
+		}
+		if len(carrier.seen) == 0 && len(carrier.points) == 0 {
+			delete(carriers, carrierID)
+		}
+	}
+}
+
+// upload sends one batch, retrying retryable failures with backoff
+func (s *GpsStreamer) upload(carrierID string, points []GpsData) {
+	var err error
+	delay := s.config.RetryDelay
+	for attempt := 0; attempt <= s.config.MaxRetries; attempt++ {
+		if attempt > 0 {
+			if sleep(s.ctx, delay) != nil {
+				break
+			}
+			delay *= 2
+		}
+
+		_, err = s.client.AddGpsData(s.ctx, carrierID, points)
+		if err == nil {
+			return
+		}
+
+		var apiErr *Error
+		var authErr *AuthError
+		if (errors.As(err, &apiErr) && !apiErr.IsRetryable()) || (errors.As(err, &authErr) && !authErr.IsRetryable()) {
+			break
+		}
+	}
+
+	if s.config.OnError != nil {
+		s.config.OnError(carrierID, points, err)
+	}
+}
@@ -0,0 +1,199 @@
+package oway
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestGpsStreamer(t *testing.T) {
+	var mu sync.Mutex
+	batches := map[string][]int{}
+	received := map[string]int{}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/v1/auth/token" {
+			w.Write([]byte(`{"accessToken": "test_token", "expiresIn": 3600}`))
+			return
+		}
+
+		var points []GpsData
+		if err := json.NewDecoder(r.Body).Decode(&points); err != nil {
+			t.Errorf("Failed to decode body: %v", err)
+		}
+		carrierID := r.URL.Path[len("/v1/carrier/") : len(r.URL.Path)-len("/gps-data")]
+
+		mu.Lock()
+		batches[carrierID] = append(batches[carrierID], len(points))
+		received[carrierID] += len(points)
+		mu.Unlock()
+		fmt.Fprintf(w, "%d", len(points))
+	}))
+	defer server.Close()
+
+	client, err := New(Config{
+		ClientID:     "client_test",
+		ClientSecret: "secret_test",
+		APIKey:       "oway_ck_test_123",
+		BaseURL:      server.URL,
+		TokenURL:     server.URL + "/v1/auth/token",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	streamer := client.NewGpsStreamer(GpsStreamerConfig{
+		BatchSize:     10,
+		FlushInterval: time.Hour,
+		QueueSize:     5,
+		OnError: func(carrierID string, points []GpsData, err error) {
+			t.Errorf("Upload failed for %s: %v", carrierID, err)
+		},
+	})
+
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	var wg sync.WaitGroup
+	for _, carrierID := range []string{"carrier_1", "carrier_2"} {
+		for vehicle := 0; vehicle < 5; vehicle++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := 0; i < 5; i++ {
+					point := GpsData{
+						VehicleId: fmt.Sprintf("truck_%d", vehicle),
+						Timestamp: base.Add(time.Duration(i) * time.Second),
+					}
+					// Every point is sent twice; duplicates must be dropped
+					for range 2 {
+						if err := streamer.Add(context.Background(), carrierID, point); err != nil {
+							t.Error(err)
+						}
+					}
+				}
+			}()
+		}
+	}
+	wg.Wait()
+
+	if err := streamer.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	for _, carrierID := range []string{"carrier_1", "carrier_2"} {
+		if received[carrierID] != 25 {
+			t.Errorf("%s: expected 25 unique points, got %d", carrierID, received[carrierID])
+		}
+		for _, size := range batches[carrierID] {
+			if size > 10 {
+				t.Errorf("%s: batch of %d exceeds BatchSize", carrierID, size)
+			}
+		}
+	}
+
+	if err := streamer.Add(context.Background(), "carrier_1", GpsData{}); err != ErrStreamerClosed {
+		t.Errorf("Expected ErrStreamerClosed, got %v", err)
+	}
+}
+
+func TestGpsStreamerClose(t *testing.T) {
+	var uploads atomic.Int32
+	var fail atomic.Bool
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/v1/auth/token" {
+			w.Write([]byte(`{"accessToken": "test_token", "expiresIn": 3600}`))
+			return
+		}
+		uploads.Add(1)
+		if fail.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+		w.Write([]byte(`1`))
+	}))
+	defer server.Close()
+	defer close(release)
+
+	client, err := New(Config{
+		ClientID:      "client_test",
+		ClientSecret:  "secret_test",
+		CarrierAPIKey: "oway_ck_test_123",
+		BaseURL:       server.URL,
+		TokenURL:      server.URL + "/v1/auth/token",
+		Retry:         &RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	point := func(i int) GpsData {
+		return GpsData{VehicleId: "truck_1", Timestamp: time.Unix(int64(i), 0)}
+	}
+
+	t.Run("should honor the Close deadline while Add is blocked on a full queue", func(t *testing.T) {
+		streamer := client.NewGpsStreamer(GpsStreamerConfig{BatchSize: 1, QueueSize: 1, MaxRetries: -1})
+
+		// The first point blocks the upload loop; the second fills the queue
+		streamer.Add(context.Background(), "carrier_1", point(0))
+		for uploads.Load() == 0 {
+			time.Sleep(time.Millisecond)
+		}
+		streamer.Add(context.Background(), "carrier_1", point(1))
+		blocked := make(chan error)
+		go func() { blocked <- streamer.Add(context.Background(), "carrier_1", point(2)) }()
+		time.Sleep(10 * time.Millisecond)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		closed := make(chan error)
+		go func() { closed <- streamer.Close(ctx) }()
+
+		select {
+		case err := <-closed:
+			if !errors.Is(err, context.DeadlineExceeded) {
+				t.Errorf("Expected context.DeadlineExceeded, got %v", err)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatal("Expected Close to return at its deadline")
+		}
+		if err := <-blocked; err != nil && !errors.Is(err, ErrStreamerClosed) {
+			t.Errorf("Expected blocked Add to end with ErrStreamerClosed, got %v", err)
+		}
+	})
+
+	t.Run("should retry uploads in one layer only", func(t *testing.T) {
+		fail.Store(true)
+		uploads.Store(0)
+		var failed atomic.Bool
+		streamer := client.NewGpsStreamer(GpsStreamerConfig{
+			BatchSize:  1,
+			MaxRetries: 2,
+			RetryDelay: time.Millisecond,
+			OnError:    func(string, []GpsData, error) { failed.Store(true) },
+		})
+		streamer.Add(context.Background(), "carrier_1", point(0))
+		if err := streamer.Close(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		if !failed.Load() {
+			t.Error("Expected OnError after all retries")
+		}
+		if uploads.Load() != 3 {
+			t.Errorf("Expected 3 upload attempts, got %d", uploads.Load())
+		}
+	})
+}
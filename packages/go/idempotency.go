@@ -0,0 +1,142 @@
+package oway
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrIdempotencyKeyReused is returned when an idempotency key is repeated
+// within Config.IdempotencyWindow for a different order or request body
+var ErrIdempotencyKeyReused = errors.New("idempotency key reused for a different request")
+
+// idempotencyKeyContextKey is used to pass per-request idempotency keys via context
+type idempotencyKeyContextKey struct{}
+
+// WithIdempotencyKey returns a context with the specified idempotency key.
+// CreateShipment, ConfirmShipment and CancelShipment send it as the
+// Idempotency-Key header, or a key generated per call when none is set. They
+// are only retried with a key set here, since the SDK cannot tell whether a
+// failed attempt reached the API.
+func WithIdempotencyKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, idempotencyKeyContextKey{}, key)
+}
+
+func idempotencyKeyFromContext(ctx context.Context) string {
+	key, _ := ctx.Value(idempotencyKeyContextKey{}).(string)
+	return key
+}
+
+// idempotencyCache remembers the result of mutating calls by idempotency key
+// so that a repeated call within the window returns the first result instead
+// of re-issuing the request. Concurrent calls with the same key wait for the
+// one in flight.
+type idempotencyCache struct {
+	window  time.Duration
+	mu      sync.Mutex
+	entries map[string]*idempotencyEntry
+}
+
+type idempotencyEntry struct {
+	target   string
+	done     chan struct{}
+	shipment *Shipment
+	err      error
+	expires  time.Time
+}
+
+func newIdempotencyCache(window time.Duration) *idempotencyCache {
+	return &idempotencyCache{
+		window:  window,
+		entries: make(map[string]*idempotencyEntry),
+	}
+}
+
+// do runs call once per key. target identifies what the call acts on; a key
+// repeated for a different target fails with ErrIdempotencyKeyReused. If the
+// call fails, one of the callers waiting on it issues the call again and the
+// rest wait on that attempt.
+func (c *idempotencyCache) do(ctx context.Context, key, target string, call func() (*Shipment, error)) (*Shipment, error) {
+	c.mu.Lock()
+	now := time.Now()
+	for k, e := range c.entries {
+		if !e.expires.IsZero() && now.After(e.expires) {
+			delete(c.entries, k)
+		}
+	}
+
+	for {
+		e, ok := c.entries[key]
+		if !ok {
+			break
+		}
+		c.mu.Unlock()
+		if e.target != target {
+			return nil, ErrIdempotencyKeyReused
+		}
+		select {
+		case <-e.done:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		if e.err == nil {
+			shipment := *e.shipment
+			return &shipment, nil
+		}
+		// The call failed and was removed from the cache; check again in
+		// case another waiter has already issued it anew
+		c.mu.Lock()
+	}
+
+	e := &idempotencyEntry{target: target, done: make(chan struct{})}
+	c.entries[key] = e
+	c.mu.Unlock()
+
+	e.shipment, e.err = call()
+
+	c.mu.Lock()
+	if e.err != nil {
+		delete(c.entries, key)
+	} else {
+		e.expires = time.Now().Add(c.window)
+	}
+	c.mu.Unlock()
+	close(e.done)
+
+	if e.err != nil {
+		return nil, e.err
+	}
+	shipment := *e.shipment
+	return &shipment, nil
+}
+
+// idempotent runs a mutating shipper call and, when the context carries an
+// idempotency key, deduplicates repeats of the key via the cache. target is
+// the order number or request body hash the call acts on.
+func (c *Client) idempotent(ctx context.Context, operation, target string, call func(ctx context.Context) (*Shipment, error)) (*Shipment, error) {
+	key := idempotencyKeyFromContext(ctx)
+	if key == "" || c.idempotency == nil {
+		return call(ctx)
+	}
+
+	apiKey, err := c.apiKeyFromContext(ctx, operation)
+	if err != nil {
+		return nil, err
+	}
+
+	cacheKey := operation + "|" + apiKey + "|" + key
+	return c.idempotency.do(ctx, cacheKey, target, func() (*Shipment, error) {
+		return call(ctx)
+	})
+}
+
+// bodyHash identifies a request body for the idempotency cache
+func bodyHash(body any) string {
+	data, _ := json.Marshal(body)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,90 @@
+package oway
+
+import (
+	"context"
+	"net/http"
+	"regexp"
+	"time"
+)
+
+// Instrumentation lets tracing and metrics libraries observe the client
+// without the SDK depending on them. All hooks are optional and must be safe
+// for concurrent use. The owayotel module
+// (github.com/Oway-Inc/oway-sdk/packages/go/owayotel) implements them with
+// OpenTelemetry.
+type Instrumentation struct {
+	// StartCall is called before the first attempt of each API call. The
+	// returned context (e.g. carrying a span) is used for every attempt and
+	// passed to EndCall.
+	StartCall func(ctx context.Context, call CallInfo) context.Context
+
+	// EndCall is called once the call has completed, after any retries
+	EndCall func(ctx context.Context, call CallInfo, result CallResult)
+
+	// InjectHeaders adds headers, such as W3C traceparent, to each attempt
+	InjectHeaders func(ctx context.Context, header http.Header)
+
+	// OnRetry is called before each retry of a call
+	OnRetry func(ctx context.Context, call CallInfo, attempt int, delay time.Duration)
+
+	// OnTokenRefresh is called after each request to the token endpoint
+	OnTokenRefresh func(ctx context.Context, duration time.Duration, err error)
+}
+
+// CallInfo describes an API call
+type CallInfo struct {
+	// Operation is the OpenAPI operationId (e.g. OperationCreateShipment),
+	// or empty for requests to unknown endpoints
+	Operation string
+
+	// Method is the HTTP method
+	Method string
+
+	// Path is the URL path
+	Path string
+
+	// OrderNumber is the shipment order number in the path, if any
+	OrderNumber string
+
+	// RequestID is the x-request-id sent with every attempt
+	RequestID string
+}
+
+// CallResult describes the outcome of an API call
+type CallResult struct {
+	// StatusCode is the HTTP status of the last attempt (0 if none was received)
+	StatusCode int
+
+	// Attempts is the number of attempts sent
+	Attempts int
+
+	// Duration is the time taken by the call, including retries
+	Duration time.Duration
+
+	// Err is the transport-level error, if the call produced no response
+	Err error
+}
+
+var orderNumberPath = regexp.MustCompile(`/v1/shipper/shipment/([^/]+)`)
+
+// newCallInfo describes the call made by req
+func newCallInfo(req *http.Request, operation, requestID string) CallInfo {
+	call := CallInfo{
+		Operation: operation,
+		Method:    req.Method,
+		Path:      req.URL.Path,
+		RequestID: requestID,
+	}
+	if m := orderNumberPath.FindStringSubmatch(req.URL.Path); m != nil {
+		call.OrderNumber = m[1]
+	}
+	return call
+}
+
+// statusCode returns the status of resp, or 0 if there is none
+func statusCode(resp *http.Response) int {
+	if resp == nil {
+		return 0
+	}
+	return resp.StatusCode
+}
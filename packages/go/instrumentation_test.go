@@ -0,0 +1,81 @@
+package oway
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestInstrumentation(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/v1/auth/token":
+			w.Write([]byte(`{"accessToken": "test_token", "expiresIn": 3600}`))
+		default:
+			if r.Header.Get("x-trace") != "trace-1" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			w.Write([]byte(`{"orderNumber": "ORD-123"}`))
+		}
+	}))
+	defer server.Close()
+
+	var mu sync.Mutex
+	var calls []CallInfo
+	var results []CallResult
+	refreshes := 0
+	client, err := New(Config{
+		ClientID:     "client_test",
+		ClientSecret: "secret_test",
+		APIKey:       "oway_sk_test_abcdef123456",
+		BaseURL:      server.URL,
+		TokenURL:     server.URL + "/v1/auth/token",
+		Instrumentation: &Instrumentation{
+			InjectHeaders: func(ctx context.Context, header http.Header) {
+				header.Set("x-trace", "trace-1")
+			},
+			EndCall: func(ctx context.Context, call CallInfo, result CallResult) {
+				mu.Lock()
+				defer mu.Unlock()
+				calls = append(calls, call)
+				results = append(results, result)
+			},
+			OnTokenRefresh: func(ctx context.Context, duration time.Duration, err error) {
+				mu.Lock()
+				defer mu.Unlock()
+				refreshes++
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := client.GetShipment(WithRequestID(context.Background(), "req-123"), "ORD-123"); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("should report each call once", func(t *testing.T) {
+		if len(calls) != 1 {
+			t.Fatalf("Expected 1 call, got %d", len(calls))
+		}
+		want := CallInfo{Operation: OperationGetShipment, Method: http.MethodGet, Path: "/v1/shipper/shipment/ORD-123", OrderNumber: "ORD-123", RequestID: "req-123"}
+		if calls[0] != want {
+			t.Errorf("Expected %+v, got %+v", want, calls[0])
+		}
+		if results[0].StatusCode != http.StatusOK || results[0].Attempts != 1 || results[0].Err != nil {
+			t.Errorf("Unexpected result %+v", results[0])
+		}
+	})
+
+	t.Run("should report token refreshes", func(t *testing.T) {
+		if refreshes != 1 {
+			t.Errorf("Expected 1 token refresh, got %d", refreshes)
+		}
+	})
+}
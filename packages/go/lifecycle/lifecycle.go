@@ -0,0 +1,176 @@
+// Package lifecycle models the Oway shipment state machine: which status
+// transitions are legal and which operations are valid in each status.
+package lifecycle
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// Status is a shipment status. Convert from the SDK's status types with
+// lifecycle.Status(*shipment.OrderStatus) or lifecycle.Status(*tracking.OrderStatus).
+type Status string
+
+// Shipment statuses in lifecycle order
+const (
+	Initialized Status = "INITIALIZED"
+	Confirmed   Status = "CONFIRMED"
+	Accepted    Status = "ACCEPTED"
+	Assigned    Status = "ASSIGNED"
+	PickedUp    Status = "PICKED_UP"
+	InTransit   Status = "IN_TRANSIT"
+	Delivered   Status = "DELIVERED"
+	Cancelled   Status = "CANCELLED"
+)
+
+// ErrInvalidState is matched (via errors.Is) by every *StateError
+var ErrInvalidState = errors.New("operation not valid in current shipment status")
+
+// StateError describes an operation attempted in a status that does not allow it
+type StateError struct {
+	// Operation is the operation attempted (e.g. "confirmShipment")
+	Operation string
+
+	// Status is the shipment's current status
+	Status Status
+
+	// Allowed lists the statuses in which the operation is valid
+	Allowed []Status
+}
+
+// Error implements the error interface
+func (e *StateError) Error() string {
+	return fmt.Sprintf("%s is not valid for a %s shipment (allowed: %v)", e.Operation, e.Status, e.Allowed)
+}
+
+// Is reports whether target is ErrInvalidState
+func (e *StateError) Is(target error) bool {
+	return target == ErrInvalidState
+}
+
+// transitions is the state graph: the statuses each status can move to directly
+var transitions = map[Status][]Status{
+	Initialized: {Confirmed, Cancelled},
+	Confirmed:   {Accepted, Assigned, Cancelled},
+	Accepted:    {Assigned, Cancelled},
+	Assigned:    {PickedUp, Cancelled},
+	PickedUp:    {InTransit, Delivered},
+	InTransit:   {Delivered},
+	Delivered:   {},
+	Cancelled:   {},
+}
+
+// Statuses returns every known status in lifecycle order
+func Statuses() []Status {
+	return []Status{Initialized, Confirmed, Accepted, Assigned, PickedUp, InTransit, Delivered, Cancelled}
+}
+
+// IsKnown returns true if s is a status in the state graph
+func IsKnown(s Status) bool {
+	_, ok := transitions[s]
+	return ok
+}
+
+// Next returns the statuses s can move to directly
+func Next(s Status) []Status {
+	return slices.Clone(transitions[s])
+}
+
+// CanTransition returns true if a shipment can move directly from one status to another
+func CanTransition(from, to Status) bool {
+	return slices.Contains(transitions[from], to)
+}
+
+// IsReachable returns true if a shipment in status from can eventually reach
+// status to. Useful when polling, where intermediate statuses may be missed.
+func IsReachable(from, to Status) bool {
+	if from == to {
+		return true
+	}
+	visited := map[Status]bool{from: true}
+	queue := []Status{from}
+	for len(queue) > 0 {
+		s := queue[0]
+		queue = queue[1:]
+		for _, next := range transitions[s] {
+			if next == to {
+				return true
+			}
+			if !visited[next] {
+				visited[next] = true
+				queue = append(queue, next)
+			}
+		}
+	}
+	return false
+}
+
+// IsTerminal returns true if no further status changes are expected
+func IsTerminal(s Status) bool {
+	return s == Delivered || s == Cancelled
+}
+
+var (
+	confirmable  = []Status{Initialized}
+	cancellable  = []Status{Initialized, Confirmed, Accepted, Assigned}
+	invoiceable  = []Status{Delivered}
+	documentable = []Status{Confirmed, Accepted, Assigned, PickedUp, InTransit, Delivered}
+)
+
+// CanConfirm returns true if a shipment in status s can be confirmed
+func CanConfirm(s Status) bool {
+	return slices.Contains(confirmable, s)
+}
+
+// CanCancel returns true if a shipment in status s can be cancelled (before pickup)
+func CanCancel(s Status) bool {
+	return slices.Contains(cancellable, s)
+}
+
+// CanGetInvoice returns true if an invoice is available for a shipment in status s
+func CanGetInvoice(s Status) bool {
+	return slices.Contains(invoiceable, s)
+}
+
+// CanGetDocument returns true if a document of the given type
+// (BILL_OF_LADING, SHIPPING_LABEL, INVOICE, POD) is available in status s
+func CanGetDocument(s Status, documentType string) bool {
+	return slices.Contains(documentStatuses(documentType), s)
+}
+
+// CheckConfirm returns a *StateError if a shipment in status s cannot be confirmed
+func CheckConfirm(s Status) error {
+	return check("confirmShipment", s, confirmable)
+}
+
+// CheckCancel returns a *StateError if a shipment in status s cannot be cancelled
+func CheckCancel(s Status) error {
+	return check("cancelShipment", s, cancellable)
+}
+
+// CheckInvoice returns a *StateError if no invoice is available in status s
+func CheckInvoice(s Status) error {
+	return check("getInvoice", s, invoiceable)
+}
+
+// CheckDocument returns a *StateError if the document type is not available in status s
+func CheckDocument(s Status, documentType string) error {
+	return check("getDocument "+documentType, s, documentStatuses(documentType))
+}
+
+func documentStatuses(documentType string) []Status {
+	switch documentType {
+	case "POD", "INVOICE":
+		return invoiceable
+	default:
+		return documentable
+	}
+}
+
+func check(operation string, s Status, allowed []Status) error {
+	if slices.Contains(allowed, s) {
+		return nil
+	}
+	return &StateError{Operation: operation, Status: s, Allowed: slices.Clone(allowed)}
+}
@@ -0,0 +1,71 @@
+package lifecycle
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestTransitions(t *testing.T) {
+	tests := []struct {
+		from, to  Status
+		direct    bool
+		reachable bool
+	}{
+		{Initialized, Confirmed, true, true},
+		{Initialized, Delivered, false, true},
+		{Confirmed, Assigned, true, true},
+		{Assigned, PickedUp, true, true},
+		{PickedUp, Cancelled, false, false},
+		{Delivered, InTransit, false, false},
+		{Cancelled, Confirmed, false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.from)+"->"+string(tt.to), func(t *testing.T) {
+			if got := CanTransition(tt.from, tt.to); got != tt.direct {
+				t.Errorf("CanTransition: expected %v, got %v", tt.direct, got)
+			}
+			if got := IsReachable(tt.from, tt.to); got != tt.reachable {
+				t.Errorf("IsReachable: expected %v, got %v", tt.reachable, got)
+			}
+		})
+	}
+
+	for _, s := range Statuses() {
+		if IsTerminal(s) != (len(Next(s)) == 0) {
+			t.Errorf("%s: terminal statuses must have no transitions", s)
+		}
+	}
+}
+
+func TestOperationChecks(t *testing.T) {
+	tests := []struct {
+		name  string
+		check func(Status) error
+		valid []Status
+	}{
+		{"confirm", CheckConfirm, []Status{Initialized}},
+		{"cancel", CheckCancel, []Status{Initialized, Confirmed, Accepted, Assigned}},
+		{"invoice", CheckInvoice, []Status{Delivered}},
+		{"pod", func(s Status) error { return CheckDocument(s, "POD") }, []Status{Delivered}},
+		{"bol", func(s Status) error { return CheckDocument(s, "BILL_OF_LADING") }, []Status{Confirmed, Accepted, Assigned, PickedUp, InTransit, Delivered}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			valid := map[Status]bool{}
+			for _, s := range tt.valid {
+				valid[s] = true
+			}
+			for _, s := range Statuses() {
+				err := tt.check(s)
+				if valid[s] && err != nil {
+					t.Errorf("%s: unexpected error %v", s, err)
+				}
+				if !valid[s] && !errors.Is(err, ErrInvalidState) {
+					t.Errorf("%s: expected ErrInvalidState, got %v", s, err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,140 @@
+package oway
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// redactedValue replaces secrets in log output
+const redactedValue = "REDACTED"
+
+// redactedFields are JSON body fields whose values are never logged. Matching
+// is case-insensitive and applies at any depth, so the phone numbers of
+// pickup and delivery addresses are covered.
+var redactedFields = map[string]bool{
+	"clientsecret": true,
+	"accesstoken":  true,
+	"phonenumber":  true,
+}
+
+// newDefaultLogger returns the logger used when Config.Logger is nil: debug
+// output to stderr with Config.Debug, otherwise nothing
+func newDefaultLogger(debug bool) *slog.Logger {
+	if !debug {
+		return slog.New(slog.DiscardHandler)
+	}
+	return slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelDebug})).With("sdk", "oway")
+}
+
+// logAttempt records one HTTP attempt. Successful responses log at debug,
+// 4xx at warn, and 5xx and transport errors at error.
+func (c *Client) logAttempt(ctx context.Context, req *http.Request, operation, requestID, apiKey string, attempt int, latency time.Duration, resp *http.Response, err error) {
+	logger := c.config.Logger
+	attrs := []slog.Attr{
+		slog.String("method", req.Method),
+		slog.String("path", req.URL.Path),
+		slog.String("operation", operation),
+		slog.String("request_id", requestID),
+		slog.Int("attempt", attempt),
+		slog.Duration("latency", latency),
+	}
+	if apiKey != "" {
+		attrs = append(attrs, slog.String("api_key", redactAPIKey(apiKey)))
+	}
+
+	level := slog.LevelDebug
+	switch {
+	case err != nil:
+		level = slog.LevelError
+		attrs = append(attrs, slog.String("error", err.Error()))
+	case resp.StatusCode >= 500:
+		level = slog.LevelError
+	case resp.StatusCode >= 400:
+		level = slog.LevelWarn
+	}
+	if resp != nil {
+		attrs = append(attrs, slog.Int("status", resp.StatusCode))
+	}
+	if !logger.Enabled(ctx, level) {
+		return
+	}
+
+	if c.config.LogBodies {
+		if body := requestBody(req); body != "" {
+			attrs = append(attrs, slog.String("request_body", body))
+		}
+		if body := responseBody(resp); body != "" {
+			attrs = append(attrs, slog.String("response_body", body))
+		}
+	}
+
+	logger.LogAttrs(ctx, level, "oway request", attrs...)
+}
+
+// requestBody returns the redacted body of a rewindable request
+func requestBody(req *http.Request) string {
+	if req.GetBody == nil {
+		return ""
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return ""
+	}
+	defer body.Close()
+	data, _ := io.ReadAll(io.LimitReader(body, 64<<10))
+	return redactJSON(data)
+}
+
+// responseBody returns the redacted response body, leaving resp.Body
+// readable for the caller
+func responseBody(resp *http.Response) string {
+	if resp == nil || resp.Body == nil {
+		return ""
+	}
+	data, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(data))
+	if err != nil {
+		return ""
+	}
+	return redactJSON(data)
+}
+
+// redactJSON replaces the values of redactedFields in a JSON body. Bodies
+// that are not JSON are omitted, since they cannot be redacted reliably.
+func redactJSON(body []byte) string {
+	if len(bytes.TrimSpace(body)) == 0 {
+		return ""
+	}
+	var value any
+	if json.Unmarshal(body, &value) != nil {
+		return "(non-JSON body omitted)"
+	}
+	redacted, _ := json.Marshal(redactValue(value))
+	return string(redacted)
+}
+
+func redactValue(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, field := range v {
+			if redactedFields[strings.ToLower(key)] {
+				v[key] = redactedValue
+			} else {
+				v[key] = redactValue(field)
+			}
+		}
+	case []any:
+		for i := range v {
+			v[i] = redactValue(v[i])
+		}
+	}
+	return value
+}
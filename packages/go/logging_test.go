@@ -0,0 +1,80 @@
+package oway
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// syncBuffer is a bytes.Buffer safe for the concurrent writes of a logger
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestLogging(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/v1/auth/token":
+			w.Write([]byte(`{"accessToken": "secret_token_value", "expiresIn": 3600}`))
+		default:
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			w.Write([]byte(`{"title": "Unprocessable", "detail": "bad zip"}`))
+		}
+	}))
+	defer server.Close()
+
+	var out syncBuffer
+	client, err := New(Config{
+		ClientID:     "client_test",
+		ClientSecret: "client_secret_value",
+		APIKey:       "oway_sk_test_abcdef123456",
+		BaseURL:      server.URL,
+		TokenURL:     server.URL + "/v1/auth/token",
+		Logger:       slog.New(slog.NewJSONHandler(&out, &slog.HandlerOptions{Level: slog.LevelDebug})),
+		LogBodies:    true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client.RequestQuote(WithRequestID(context.Background(), "req-123"), &QuoteRequest{
+		PickupAddress:   Address{PhoneNumber: "+15550123456"},
+		DeliveryAddress: Address{PhoneNumber: "+15555678901"},
+	})
+	logs := out.String()
+
+	t.Run("should log request attributes", func(t *testing.T) {
+		for _, want := range []string{`"level":"WARN"`, `"operation":"requestQuote"`, `"request_id":"req-123"`, `"status":422`, `"attempt":1`, `"latency":`, `"api_key":"oway_sk_test_****3456"`, `bad zip`, `oway access token refreshed`} {
+			if !strings.Contains(logs, want) {
+				t.Errorf("Expected logs to contain %s:\n%s", want, logs)
+			}
+		}
+	})
+
+	t.Run("should redact secrets", func(t *testing.T) {
+		for _, secret := range []string{"secret_token_value", "client_secret_value", "oway_sk_test_abcdef123456", "+15550123456", "+15555678901"} {
+			if strings.Contains(logs, secret) {
+				t.Errorf("Expected %s to be redacted:\n%s", secret, logs)
+			}
+		}
+	})
+}
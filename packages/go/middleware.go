@@ -0,0 +1,66 @@
+package oway
+
+import (
+	"context"
+	"net/http"
+)
+
+// Middleware wraps an http.RoundTripper to observe or modify calls, e.g. for
+// auditing, header injection or fault injection. Use CallInfoFromContext on
+// the request context for the operation, order number and request ID.
+//
+// Config.Middleware and Config.AttemptMiddleware run at fixed points of the
+// client's pipeline, outermost first:
+//
+//	Middleware           once per call, as sent by the caller
+//	Instrumentation      StartCall / EndCall
+//	retries              including the 401 replay with a new token
+//	  auth               Authorization, x-oway-api-key, Idempotency-Key, x-request-id
+//	  rate limit         Config.RateLimit
+//	  circuit breaker    Config.CircuitBreaker
+//	  logging            Config.Logger
+//	  AttemptMiddleware  once per attempt
+//	  HTTPClient         Config.HTTPClient.Transport
+//
+// Within each list the first middleware is the outermost.
+type Middleware func(next http.RoundTripper) http.RoundTripper
+
+// RoundTripperFunc adapts a function to http.RoundTripper
+type RoundTripperFunc func(*http.Request) (*http.Response, error)
+
+// RoundTrip implements http.RoundTripper
+func (f RoundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// callInfoContextKey is used to pass the CallInfo of a call via context
+type callInfoContextKey struct{}
+
+// CallInfoFromContext returns the call a request belongs to. It is set for
+// requests passed to Middleware and AttemptMiddleware.
+func CallInfoFromContext(ctx context.Context) (CallInfo, bool) {
+	call, ok := ctx.Value(callInfoContextKey{}).(CallInfo)
+	return call, ok
+}
+
+// chainMiddleware wraps next in middleware, the first being the outermost
+func chainMiddleware(middleware []Middleware, next http.RoundTripper) http.RoundTripper {
+	for i := len(middleware) - 1; i >= 0; i-- {
+		next = middleware[i](next)
+	}
+	return next
+}
+
+// callTransport fixes the request ID and CallInfo of a call before any
+// middleware runs, so every stage sees the same ones
+type callTransport struct {
+	next http.RoundTripper
+}
+
+func (t *callTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	requestID := requestIDFromContext(ctx)
+	ctx = WithRequestID(ctx, requestID)
+	ctx = context.WithValue(ctx, callInfoContextKey{}, newCallInfo(req, operationFor(req), requestID))
+	return t.next.RoundTrip(req.WithContext(ctx))
+}
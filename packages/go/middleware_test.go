@@ -0,0 +1,115 @@
+package oway
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestMiddleware(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/v1/auth/token":
+			w.Write([]byte(`{"accessToken": "test_token", "expiresIn": 3600}`))
+		default:
+			if r.Header.Get("x-audit") != "attempt" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			w.Write([]byte(`{"orderNumber": "ORD-123"}`))
+		}
+	}))
+	defer server.Close()
+
+	var mu sync.Mutex
+	var trace []string
+	record := func(name string) Middleware {
+		return func(next http.RoundTripper) http.RoundTripper {
+			return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				mu.Lock()
+				trace = append(trace, name)
+				mu.Unlock()
+				return next.RoundTrip(req)
+			})
+		}
+	}
+
+	var calls []CallInfo
+	var authorized atomic.Bool
+	var faults atomic.Int32
+	client, err := New(Config{
+		ClientID:     "client_test",
+		ClientSecret: "secret_test",
+		APIKey:       "oway_sk_test_abcdef123456",
+		BaseURL:      server.URL,
+		TokenURL:     server.URL + "/v1/auth/token",
+		Retry:        &RetryPolicy{MaxAttempts: 2, BaseDelay: time.Millisecond},
+		Middleware: []Middleware{
+			record("call-1"),
+			record("call-2"),
+			func(next http.RoundTripper) http.RoundTripper {
+				return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+					call, _ := CallInfoFromContext(req.Context())
+					calls = append(calls, call)
+					return next.RoundTrip(req)
+				})
+			},
+		},
+		AttemptMiddleware: []Middleware{
+			record("attempt"),
+			func(next http.RoundTripper) http.RoundTripper {
+				return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+					authorized.Store(req.Header.Get("Authorization") == "Bearer test_token")
+					if faults.Add(1) == 1 {
+						return nil, errors.New("injected fault")
+					}
+					req.Header.Set("x-audit", "attempt")
+					return next.RoundTrip(req)
+				})
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := client.GetShipment(WithRequestID(context.Background(), "req-123"), "ORD-123"); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("should run call middleware once and attempt middleware per attempt, in order", func(t *testing.T) {
+		want := []string{"call-1", "call-2", "attempt", "attempt"}
+		if len(trace) != len(want) {
+			t.Fatalf("Expected %v, got %v", want, trace)
+		}
+		for i := range want {
+			if trace[i] != want[i] {
+				t.Fatalf("Expected %v, got %v", want, trace)
+			}
+		}
+	})
+
+	t.Run("should retry faults injected by attempt middleware", func(t *testing.T) {
+		if faults.Load() != 2 {
+			t.Errorf("Expected 2 attempts, got %d", faults.Load())
+		}
+	})
+
+	t.Run("should run attempt middleware after authentication", func(t *testing.T) {
+		if !authorized.Load() {
+			t.Error("Expected Authorization header in attempt middleware")
+		}
+	})
+
+	t.Run("should expose call info to middleware", func(t *testing.T) {
+		if len(calls) != 1 || calls[0].Operation != OperationGetShipment || calls[0].OrderNumber != "ORD-123" || calls[0].RequestID != "req-123" {
+			t.Errorf("Unexpected call info %+v", calls)
+		}
+	})
+}
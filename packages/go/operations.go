@@ -0,0 +1,78 @@
+package oway
+
+import (
+	"net/http"
+	"regexp"
+)
+
+// Operation names match the operationId values in openapi/spec.json
+const (
+	OperationGetToken            = "getToken"
+	OperationRequestQuote        = "requestQuote"
+	OperationGetQuote            = "getQuote"
+	OperationCreateShipment      = "createShipment"
+	OperationGetShipment         = "getShipment"
+	OperationConfirmShipment     = "confirmShipment"
+	OperationCancelShipment      = "cancelShipment"
+	OperationTrackShipment       = "trackShipment"
+	OperationGetInvoice          = "getInvoice"
+	OperationGetDocument         = "getDocument"
+	OperationGetCarrierApiConfig = "getCarrierApiConfig"
+	OperationAddGpsData          = "addGpsData"
+	OperationGetJobs             = "getJobs"
+	OperationAddTrips            = "addTrips"
+)
+
+var operationRoutes = []struct {
+	method    string
+	path      *regexp.Regexp
+	operation string
+}{
+	{http.MethodPost, regexp.MustCompile(`/v1/auth/token$`), OperationGetToken},
+	{http.MethodPost, regexp.MustCompile(`/v1/shipper/quote$`), OperationRequestQuote},
+	{http.MethodGet, regexp.MustCompile(`/v1/shipper/quote/[^/]+$`), OperationGetQuote},
+	{http.MethodPost, regexp.MustCompile(`/v1/shipper/shipment$`), OperationCreateShipment},
+	{http.MethodGet, regexp.MustCompile(`/v1/shipper/shipment/[^/]+$`), OperationGetShipment},
+	{http.MethodPut, regexp.MustCompile(`/v1/shipper/shipment/[^/]+/confirm$`), OperationConfirmShipment},
+	{http.MethodPut, regexp.MustCompile(`/v1/shipper/shipment/[^/]+/cancel$`), OperationCancelShipment},
+	{http.MethodGet, regexp.MustCompile(`/v1/shipper/shipment/[^/]+/tracking$`), OperationTrackShipment},
+	{http.MethodGet, regexp.MustCompile(`/v1/shipper/shipment/[^/]+/invoice$`), OperationGetInvoice},
+	{http.MethodGet, regexp.MustCompile(`/v1/shipper/shipment/[^/]+/document/[^/]+$`), OperationGetDocument},
+	{http.MethodGet, regexp.MustCompile(`/v1/carrier/[^/]+$`), OperationGetCarrierApiConfig},
+	{http.MethodPost, regexp.MustCompile(`/v1/carrier/[^/]+/gps-data$`), OperationAddGpsData},
+	{http.MethodGet, regexp.MustCompile(`/v1/carrier/[^/]+/jobs$`), OperationGetJobs},
+	{http.MethodPost, regexp.MustCompile(`/v1/carrier/[^/]+/trips$`), OperationAddTrips},
+}
+
+// operationFor returns the operation name for a request, or "" if the
+// request does not match a known endpoint
+func operationFor(req *http.Request) string {
+	for _, route := range operationRoutes {
+		if req.Method == route.method && route.path.MatchString(req.URL.Path) {
+			return route.operation
+		}
+	}
+	return ""
+}
+
+// isMutatingOperation reports whether replaying the operation could change
+// server-side state more than once (e.g. booking the same freight twice)
+func isMutatingOperation(operation string) bool {
+	switch operation {
+	case OperationCreateShipment, OperationConfirmShipment, OperationCancelShipment:
+		return true
+	default:
+		return false
+	}
+}
+
+// isCarrierOperation reports whether the operation is a carrier endpoint,
+// which requires a carrier (oway_ck_) API key
+func isCarrierOperation(operation string) bool {
+	switch operation {
+	case OperationGetCarrierApiConfig, OperationAddGpsData, OperationGetJobs, OperationAddTrips:
+		return true
+	default:
+		return false
+	}
+}
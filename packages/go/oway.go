@@ -1,16 +1,19 @@
 package oway
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
 	"sync"
 	"time"
 
 	"github.com/Oway-Inc/oway-sdk/packages/go/client"
+	"github.com/Oway-Inc/oway-sdk/packages/go/lifecycle"
+	"github.com/Oway-Inc/oway-sdk/packages/go/validate"
+	"github.com/google/uuid"
 )
 
 // Config holds configuration for the Oway client
@@ -26,26 +29,110 @@ type Config struct {
 	// Multi-company: Provide per-request
 	APIKey string
 
+	// ShipperAPIKey is the default oway_sk_ key for shipper endpoints,
+	// overriding APIKey (optional)
+	ShipperAPIKey string
+
+	// CarrierAPIKey is the default oway_ck_ key for carrier endpoints,
+	// overriding APIKey (optional)
+	CarrierAPIKey string
+
+	// Companies maps tenant IDs selected with WithCompany to API keys (optional)
+	Companies *CompanyRegistry
+
 	// BaseURL is the Oway API base URL
 	BaseURL string
 
 	// TokenURL is the M2M token endpoint
 	TokenURL string
 
-	// HTTPClient is the underlying HTTP client
+	// TokenRefreshFraction is the fraction of the token lifetime (expiresIn)
+	// after which it is renewed (default: 0.8)
+	TokenRefreshFraction float64
+
+	// TokenStore shares access tokens between clients and processes
+	// (default: a MemoryTokenStore private to this client)
+	TokenStore TokenStore
+
+	// HTTPClient is the underlying HTTP client. Its Timeout applies to each
+	// attempt (and each token request); bound a whole call, including
+	// retries, with a context deadline.
 	HTTPClient *http.Client
 
-	// Debug enables debug logging
+	// Retry controls retries of transient failures (default: DefaultRetryPolicy)
+	// Use &RetryPolicy{MaxAttempts: 1} to disable retries
+	Retry *RetryPolicy
+
+	// RateLimit enables client-side rate limiting, globally and per company
+	// API key (default: nil, unlimited)
+	RateLimit *RateLimit
+
+	// CircuitBreaker enables a circuit breaker per endpoint that fails calls
+	// fast with ErrCircuitOpen during outages (default: nil, disabled)
+	CircuitBreaker *CircuitBreaker
+
+	// IdempotencyWindow is how long results of CreateShipment, ConfirmShipment
+	// and CancelShipment are remembered by idempotency key (default: 10 minutes)
+	// A negative value disables the client-side dedup cache
+	IdempotencyWindow time.Duration
+
+	// Preflight checks the shipment status with GetShipment before
+	// ConfirmShipment, CancelShipment, GetInvoice and GetDocument, returning a
+	// *lifecycle.StateError instead of sending a call the API would reject
+	Preflight bool
+
+	// ValidateRequests checks requests offline with the validate package before
+	// RequestQuote and CreateShipment, returning a *validate.ValidationError
+	// listing every invalid field instead of sending the call
+	ValidateRequests bool
+
+	// Logger receives structured logs of token refreshes, requests, retries
+	// and errors (default: none, or debug output to stderr with Debug).
+	// Bearer tokens, client secrets and API keys are never logged in full.
+	Logger *slog.Logger
+
+	// LogBodies adds JSON request and response bodies to request logs, with
+	// clientSecret, accessToken and phone numbers redacted
+	LogBodies bool
+
+	// Debug enables debug logging to stderr when Logger is nil
 	Debug bool
+
+	// Middleware wraps each call outside retries and authentication, and
+	// AttemptMiddleware wraps each attempt after authentication, rate limiting
+	// and the circuit breaker, inside logging. See Middleware for the order.
+	Middleware        []Middleware
+	AttemptMiddleware []Middleware
+
+	// Instrumentation receives tracing and metrics hooks for every call and
+	// token refresh (default: nil, disabled)
+	Instrumentation *Instrumentation
 }
 
 // Client is the main Oway SDK client
 type Client struct {
-	config      Config
-	client      *client.ClientWithResponses
-	token       string
-	tokenExpiry time.Time
-	tokenMutex  sync.RWMutex
+	*session
+	config Config
+	client *client.ClientWithResponses
+
+	// apiKey is pinned by ForCompany and overrides every other key source
+	apiKey string
+}
+
+// session is the state shared by a Client and its ForCompany views
+type session struct {
+	token          string
+	tokenExpiry    time.Time
+	tokenRefreshAt time.Time
+	tokenRetryAt   time.Time
+	tokenMutex     sync.RWMutex
+	tokenCall      *tokenCall
+	tokenCallMutex sync.Mutex
+	refresher      *tokenRefresher
+	refresherMutex sync.Mutex
+	idempotency    *idempotencyCache
+	limiter        *rateLimiter
+	breakers       *circuitBreakers
 }
 
 // New creates a new Oway client
@@ -61,34 +148,80 @@ func New(config Config) (*Client, error) {
 	if config.TokenURL == "" {
 		config.TokenURL = "https://api.sandbox.oway.io/v1/auth/token"
 	}
+	if config.TokenRefreshFraction <= 0 || config.TokenRefreshFraction >= 1 {
+		config.TokenRefreshFraction = 0.8
+	}
+	if config.TokenStore == nil {
+		config.TokenStore = NewMemoryTokenStore()
+	}
+	if config.Logger == nil {
+		config.Logger = newDefaultLogger(config.Debug)
+	}
 	if config.HTTPClient == nil {
 		config.HTTPClient = &http.Client{Timeout: 30 * time.Second}
 	}
+	if config.Retry == nil {
+		config.Retry = DefaultRetryPolicy()
+	}
+	if config.IdempotencyWindow == 0 {
+		config.IdempotencyWindow = 10 * time.Minute
+	}
 
-	c := &Client{config: config}
-
-	authHTTPClient := &http.Client{
-		Timeout: config.HTTPClient.Timeout,
-		Transport: &authenticatedTransport{
-			client:    c,
-			transport: config.HTTPClient.Transport,
-		},
+	c := &Client{session: &session{}, config: config}
+	if config.IdempotencyWindow > 0 {
+		c.idempotency = newIdempotencyCache(config.IdempotencyWindow)
+	}
+	if config.RateLimit != nil {
+		c.limiter = newRateLimiter(*config.RateLimit)
+	}
+	if config.CircuitBreaker != nil {
+		c.breakers = newCircuitBreakers(*config.CircuitBreaker)
 	}
 
-	oapiClient, err := client.NewClientWithResponses(config.BaseURL, client.WithHTTPClient(authHTTPClient))
+	oapiClient, err := c.newAPIClient()
 	if err != nil {
 		return nil, fmt.Errorf("failed to create client: %w", err)
 	}
 
 	c.client = oapiClient
 
-	if config.Debug {
-		fmt.Printf("[Oway] SDK initialized (M2M, default API key: %v)\n", config.APIKey != "")
-	}
+	config.Logger.Debug("oway client initialized", "base_url", config.BaseURL, "api_key", redactAPIKey(config.APIKey))
 
 	return c, nil
 }
 
+// newAPIClient creates the oapi-codegen client that sends requests through
+// an authenticatedTransport for c
+func (c *Client) newAPIClient() (*client.ClientWithResponses, error) {
+	transport := c.config.HTTPClient.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	var rt http.RoundTripper = &authenticatedTransport{
+		client:    c,
+		transport: chainMiddleware(c.config.AttemptMiddleware, transport),
+	}
+	// No Timeout here: it would span retries and their backoff, so
+	// roundTripOnce applies HTTPClient.Timeout to each attempt instead
+	authHTTPClient := &http.Client{
+		Transport: &callTransport{next: chainMiddleware(c.config.Middleware, rt)},
+	}
+	return client.NewClientWithResponses(c.config.BaseURL, client.WithHTTPClient(authHTTPClient))
+}
+
+// ForCompany returns a view of the client that sends apiKey as the
+// x-oway-api-key on every call, for both shipper and carrier methods. Views
+// are cheap and share the parent's token cache, idempotency cache and
+// transport, so one can be created per request:
+//
+//	quote, err := client.ForCompany(acmeKey).RequestQuote(ctx, req)
+func (c *Client) ForCompany(apiKey string) *Client {
+	view := &Client{session: c.session, config: c.config, apiKey: apiKey}
+	// Cannot fail: New already parsed the same BaseURL
+	view.client, _ = view.newAPIClient()
+	return view
+}
+
 // GetClient returns the underlying oapi-codegen client
 func (c *Client) GetClient() *client.ClientWithResponses {
 	return c.client
@@ -99,32 +232,202 @@ type authenticatedTransport struct {
 	transport http.RoundTripper
 }
 
-func (t *authenticatedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+func (t *authenticatedTransport) RoundTrip(req *http.Request) (resp *http.Response, err error) {
+	ctx := req.Context()
+	operation := operationFor(req)
+	// Mutating calls always send an Idempotency-Key, generated per call when
+	// the caller sets none, so the API can deduplicate them. They are only
+	// retried with a caller's key, which the caller can also reuse to repeat
+	// the call safely.
+	idempotencyKey, callerKey := "", false
+	if isMutatingOperation(operation) {
+		idempotencyKey = idempotencyKeyFromContext(ctx)
+		callerKey = idempotencyKey != ""
+		if !callerKey {
+			idempotencyKey = uuid.NewString()
+		}
+	}
+	policy := t.client.config.Retry.forOperation(operation, callerKey)
+	if ctx.Value(noRetryContextKey{}) != nil {
+		policy.MaxAttempts = 1
+	}
+	requestID := requestIDFromContext(ctx)
+	ids := capturedRequestIDs(ctx)
+	if ids != nil {
+		*ids = RequestIDs{Sent: requestID}
+	}
+
+	call := newCallInfo(req, operation, requestID)
+	ctx = context.WithValue(ctx, callInfoContextKey{}, call)
+	hooks := t.client.config.Instrumentation
+	if hooks == nil {
+		hooks = &Instrumentation{}
+	}
+	if hooks.StartCall != nil {
+		ctx = hooks.StartCall(ctx, call)
+	}
+	req = req.WithContext(ctx)
+	attempts, status := 0, 0
+	if hooks.EndCall != nil {
+		start := time.Now()
+		defer func() {
+			hooks.EndCall(ctx, call, CallResult{StatusCode: status, Attempts: attempts, Duration: time.Since(start), Err: err})
+		}()
+	}
+
+	apiKey, err := t.client.apiKeyFromContext(ctx, operation)
+	if err == nil {
+		err = validateAPIKey(operation, apiKey)
+	}
+	if err != nil {
+		return nil, &RequestError{RequestID: requestID, Err: err}
+	}
+	if req.Body != nil && req.GetBody == nil {
+		policy.MaxAttempts = 1
+	}
+
+	// A request whose body can be rewound is replayed once with a new token
+	// if the API rejects the cached one
+	reauthorize := req.Body == nil || req.GetBody != nil
+
+	for attempt := 1; ; attempt++ {
+		resp, token, err := t.roundTripOnce(req, operation, requestID, apiKey, idempotencyKey, attempt)
+		attempts, status = attempts+1, statusCode(resp)
+		ids.observe(resp)
+		if err == nil && resp.StatusCode == http.StatusUnauthorized && reauthorize {
+			reauthorize = false
+			drain(resp)
+			t.client.config.Logger.WarnContext(ctx, "oway access token rejected; refreshing", "operation", operation, "request_id", requestID)
+			t.client.invalidateToken(token)
+
+			resp, _, err = t.roundTripOnce(req, operation, requestID, apiKey, idempotencyKey, attempt)
+			attempts, status = attempts+1, statusCode(resp)
+			ids.observe(resp)
+			if err == nil && resp.StatusCode == http.StatusUnauthorized {
+				return nil, &RequestError{RequestID: requestID, Err: tokenRejectedError(req, operation, resp)}
+			}
+		}
+		// Token failures were already retried under the token policy by
+		// refreshToken; retrying the call would multiply the token requests
+		var authErr *AuthError
+		if attempt >= policy.MaxAttempts || errors.As(err, &authErr) || !shouldRetry(ctx, resp, err) {
+			if err != nil {
+				return nil, &RequestError{RequestID: requestID, Err: err}
+			}
+			return resp, nil
+		}
+
+		delay := policy.backoff(attempt)
+		if wait := retryAfter(resp); wait > 0 {
+			if policy.MaxDelay > 0 && wait > policy.MaxDelay {
+				return resp, err
+			}
+			delay = wait
+		}
+		drain(resp)
+
+		t.client.config.Logger.WarnContext(ctx, "retrying oway request", "operation", operation, "request_id", requestID, "attempt", attempt, "delay", delay)
+		if hooks.OnRetry != nil {
+			hooks.OnRetry(ctx, call, attempt, delay)
+		}
+		if err := sleep(ctx, delay); err != nil {
+			return nil, &RequestError{RequestID: requestID, Err: err}
+		}
+	}
+}
+
+// roundTripOnce sends a single attempt, returning the access token it used
+func (t *authenticatedTransport) roundTripOnce(req *http.Request, operation, requestID, apiKey, idempotencyKey string, attempt int) (*http.Response, string, error) {
 	token, err := t.client.getAccessToken(req.Context())
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 
 	req = req.Clone(req.Context())
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, "", err
+		}
+		req.Body = body
+	}
 	req.Header.Set("Authorization", "Bearer "+token)
 
 	// Add company API key if present in request context or default
-	apiKey := req.Context().Value(companyAPIKeyContextKey{})
-	if apiKey == nil {
-		apiKey = t.client.config.APIKey
+	if apiKey != "" {
+		req.Header.Set("x-oway-api-key", apiKey)
 	}
-	if apiKey != nil && apiKey != "" {
-		req.Header.Set("x-oway-api-key", apiKey.(string))
+
+	// Reused across retries so the server can deduplicate mutating calls
+	if idempotencyKey != "" {
+		req.Header.Set("Idempotency-Key", idempotencyKey)
 	}
 
-	req.Header.Set("x-request-id", fmt.Sprintf("%d", time.Now().UnixNano()))
+	req.Header.Set(requestIDHeader, requestID)
+	if hooks := t.client.config.Instrumentation; hooks != nil && hooks.InjectHeaders != nil {
+		hooks.InjectHeaders(req.Context(), req.Header)
+	}
 
-	transport := t.transport
-	if transport == nil {
-		transport = http.DefaultTransport
+	limiter := t.client.limiter
+	if limiter != nil {
+		if err := limiter.wait(req.Context(), apiKey); err != nil {
+			return nil, "", err
+		}
 	}
 
-	return transport.RoundTrip(req)
+	var done func(outcome attemptOutcome)
+	if breakers := t.client.breakers; breakers != nil {
+		if done, err = breakers.allow(breakerKey(req)); err != nil {
+			return nil, "", err
+		}
+	}
+
+	// Config.HTTPClient.Timeout bounds each attempt rather than the call, so
+	// an attempt that times out can still be retried
+	cancel := context.CancelFunc(func() {})
+	if timeout := t.client.config.HTTPClient.Timeout; timeout > 0 {
+		var ctx context.Context
+		ctx, cancel = context.WithTimeout(req.Context(), timeout)
+		req = req.WithContext(ctx)
+	}
+
+	start := time.Now()
+	resp, err := t.transport.RoundTrip(req)
+	if err != nil || resp.Body == nil {
+		cancel()
+	} else {
+		resp.Body = &cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+	}
+	t.client.logAttempt(req.Context(), req, operation, requestID, apiKey, attempt, time.Since(start), resp, err)
+	if done != nil {
+		done(breakerOutcome(resp, err))
+	}
+	if limiter != nil && err == nil {
+		limiter.observe(apiKey, resp)
+	}
+	return resp, token, err
+}
+
+// cancelOnClose releases an attempt's timeout once its body is closed
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelOnClose) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
+// tokenRejectedError reports a 401 that persisted after fetching a new token
+func tokenRejectedError(req *http.Request, operation string, resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	resp.Body.Close()
+	if operation == "" {
+		operation = req.Method + " " + req.URL.Path
+	}
+	return fmt.Errorf("%w: %w", ErrTokenRejected, newAPIError(operation, resp, body))
 }
 
 // companyAPIKeyContextKey is used to pass per-request API keys via context
@@ -135,76 +438,66 @@ func WithCompanyAPIKey(ctx context.Context, apiKey string) context.Context {
 	return context.WithValue(ctx, companyAPIKeyContextKey{}, apiKey)
 }
 
-func (c *Client) getAccessToken(ctx context.Context) (string, error) {
-	c.tokenMutex.RLock()
-	if c.token != "" && time.Now().Add(5*time.Minute).Before(c.tokenExpiry) {
-		token := c.token
-		c.tokenMutex.RUnlock()
-		return token, nil
+// apiKeyFromContext returns the key pinned by ForCompany, the company API key
+// from context, the key of the tenant selected with WithCompany, or the
+// default for the operation
+func (c *Client) apiKeyFromContext(ctx context.Context, operation string) (string, error) {
+	if c.apiKey != "" {
+		return c.apiKey, nil
 	}
-	c.tokenMutex.RUnlock()
-
-	c.tokenMutex.Lock()
-	defer c.tokenMutex.Unlock()
-
-	if c.token != "" && time.Now().Add(5*time.Minute).Before(c.tokenExpiry) {
-		return c.token, nil
+	if apiKey, ok := ctx.Value(companyAPIKeyContextKey{}).(string); ok {
+		return apiKey, nil
 	}
-
-	token, expiry, err := c.refreshToken(ctx)
-	if err != nil {
-		return "", err
+	if tenantID, ok := ctx.Value(tenantContextKey{}).(string); ok {
+		if c.config.Companies == nil {
+			return "", fmt.Errorf("WithCompany(%q) requires Config.Companies", tenantID)
+		}
+		return c.config.Companies.APIKey(tenantID)
 	}
-
-	c.token = token
-	c.tokenExpiry = expiry
-	return token, nil
+	return c.defaultAPIKey(operation), nil
 }
 
-func (c *Client) refreshToken(ctx context.Context) (string, time.Time, error) {
-	reqBody, _ := json.Marshal(map[string]string{
-		"clientId":     c.config.ClientID,
-		"clientSecret": c.config.ClientSecret,
-	})
-
-	req, err := http.NewRequestWithContext(ctx, "POST", c.config.TokenURL, nil)
-	if err != nil {
-		return "", time.Time{}, err
-	}
-
-	req.Header.Set("Content-Type", "application/json")
-	req.Body = io.NopCloser(bytes.NewReader(reqBody))
+// getAccessToken returns a valid access token. A token past its refresh
+// point but not yet expired is returned immediately while a refresh runs in
+// the background; callers only wait when no usable token is cached.
+func (c *Client) getAccessToken(ctx context.Context) (string, error) {
+	c.tokenMutex.RLock()
+	token, expiry, refreshAt, retryAt := c.token, c.tokenExpiry, c.tokenRefreshAt, c.tokenRetryAt
+	c.tokenMutex.RUnlock()
 
-	resp, err := c.config.HTTPClient.Do(req)
-	if err != nil {
-		return "", time.Time{}, err
+	now := time.Now()
+	if token != "" && now.Before(refreshAt) {
+		return token, nil
 	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return "", time.Time{}, fmt.Errorf("M2M token request failed: %d %s", resp.StatusCode, string(body))
+	if token != "" && now.Add(tokenExpiryMargin).Before(expiry) {
+		if now.After(retryAt) {
+			c.startTokenRefresh()
+		}
+		return token, nil
 	}
 
-	var tokenResp struct {
-		AccessToken string `json:"accessToken"`
-		ExpiresIn   int    `json:"expiresIn"`
+	call := c.startTokenRefresh()
+	select {
+	case <-call.done:
+		return call.token, call.err
+	case <-ctx.Done():
+		return "", ctx.Err()
 	}
-
-	json.NewDecoder(resp.Body).Decode(&tokenResp)
-
-	expiry := time.Now().Add(time.Duration(tokenResp.ExpiresIn) * time.Second)
-	return tokenResp.AccessToken, expiry, nil
 }
 
 // RequestQuote requests a shipping quote
 func (c *Client) RequestQuote(ctx context.Context, req *QuoteRequest) (*Quote, error) {
+	if c.config.ValidateRequests {
+		if err := validate.QuoteRequest(req); err != nil {
+			return nil, err
+		}
+	}
 	res, err := c.client.RequestQuoteWithResponse(ctx, client.RequestQuoteJSONRequestBody(*req))
 	if err != nil {
 		return nil, err
 	}
 	if res.StatusCode() != http.StatusOK {
-		return nil, fmt.Errorf("request quote failed: status %d", res.StatusCode())
+		return nil, newAPIError("request quote", res.HTTPResponse, res.Body, res.JSON400, res.JSON401, res.JSON403, res.JSON422, res.JSON500)
 	}
 	if res.JSON200 == nil {
 		return nil, fmt.Errorf("unexpected empty response body")
@@ -213,6 +506,8 @@ func (c *Client) RequestQuote(ctx context.Context, req *QuoteRequest) (*Quote, e
 }
 
 // RequestQuoteForCompany requests a quote for a specific company
+//
+// Deprecated: use c.ForCompany(companyAPIKey).RequestQuote
 func (c *Client) RequestQuoteForCompany(ctx context.Context, req *QuoteRequest, companyAPIKey string) (*Quote, error) {
 	ctx = WithCompanyAPIKey(ctx, companyAPIKey)
 	return c.RequestQuote(ctx, req)
@@ -220,20 +515,29 @@ func (c *Client) RequestQuoteForCompany(ctx context.Context, req *QuoteRequest,
 
 // CreateShipment creates a shipment
 func (c *Client) CreateShipment(ctx context.Context, req *ShipmentRequest) (*Shipment, error) {
-	res, err := c.client.CreateShipmentWithResponse(ctx, client.CreateShipmentJSONRequestBody(*req))
-	if err != nil {
-		return nil, err
-	}
-	if res.StatusCode() != http.StatusOK {
-		return nil, fmt.Errorf("create shipment failed: status %d", res.StatusCode())
-	}
-	if res.JSON200 == nil {
-		return nil, fmt.Errorf("unexpected empty response body")
-	}
-	return res.JSON200, nil
+	if c.config.ValidateRequests {
+		if err := validate.ShipmentRequest(req); err != nil {
+			return nil, err
+		}
+	}
+	return c.idempotent(ctx, OperationCreateShipment, bodyHash(req), func(ctx context.Context) (*Shipment, error) {
+		res, err := c.client.CreateShipmentWithResponse(ctx, client.CreateShipmentJSONRequestBody(*req))
+		if err != nil {
+			return nil, err
+		}
+		if res.StatusCode() != http.StatusOK {
+			return nil, newAPIError("create shipment", res.HTTPResponse, res.Body, res.JSON400, res.JSON401, res.JSON403, res.JSON422, res.JSON500)
+		}
+		if res.JSON200 == nil {
+			return nil, fmt.Errorf("unexpected empty response body")
+		}
+		return res.JSON200, nil
+	})
 }
 
 // CreateShipmentForCompany creates a shipment for a specific company
+//
+// Deprecated: use c.ForCompany(companyAPIKey).CreateShipment
 func (c *Client) CreateShipmentForCompany(ctx context.Context, req *ShipmentRequest, companyAPIKey string) (*Shipment, error) {
 	ctx = WithCompanyAPIKey(ctx, companyAPIKey)
 	return c.CreateShipment(ctx, req)
@@ -241,20 +545,29 @@ func (c *Client) CreateShipmentForCompany(ctx context.Context, req *ShipmentRequ
 
 // ConfirmShipment confirms a shipment by order number
 func (c *Client) ConfirmShipment(ctx context.Context, orderNumber string) (*Shipment, error) {
-	res, err := c.client.ConfirmShipmentWithResponse(ctx, orderNumber)
-	if err != nil {
-		return nil, err
-	}
-	if res.StatusCode() != http.StatusOK {
-		return nil, fmt.Errorf("confirm shipment failed: status %d", res.StatusCode())
-	}
-	if res.JSON200 == nil {
-		return nil, fmt.Errorf("unexpected empty response body")
-	}
-	return res.JSON200, nil
+	// Inside the idempotent call so a replayed key returns the cached result
+	// rather than failing preflight against the status it produced
+	return c.idempotent(ctx, OperationConfirmShipment, orderNumber, func(ctx context.Context) (*Shipment, error) {
+		if err := c.preflight(ctx, orderNumber, lifecycle.CheckConfirm); err != nil {
+			return nil, err
+		}
+		res, err := c.client.ConfirmShipmentWithResponse(ctx, orderNumber)
+		if err != nil {
+			return nil, err
+		}
+		if res.StatusCode() != http.StatusOK {
+			return nil, newAPIError("confirm shipment", res.HTTPResponse, res.Body, res.JSON400, res.JSON401, res.JSON403, res.JSON404, res.JSON500)
+		}
+		if res.JSON200 == nil {
+			return nil, fmt.Errorf("unexpected empty response body")
+		}
+		return res.JSON200, nil
+	})
 }
 
 // ConfirmShipmentForCompany confirms a shipment for a specific company
+//
+// Deprecated: use c.ForCompany(companyAPIKey).ConfirmShipment
 func (c *Client) ConfirmShipmentForCompany(ctx context.Context, orderNumber string, companyAPIKey string) (*Shipment, error) {
 	ctx = WithCompanyAPIKey(ctx, companyAPIKey)
 	return c.ConfirmShipment(ctx, orderNumber)
@@ -267,7 +580,7 @@ func (c *Client) TrackShipment(ctx context.Context, orderNumber string) (*Tracki
 		return nil, err
 	}
 	if res.StatusCode() != http.StatusOK {
-		return nil, fmt.Errorf("track shipment failed: status %d", res.StatusCode())
+		return nil, newAPIError("track shipment", res.HTTPResponse, res.Body, res.JSON400, res.JSON401, res.JSON403, res.JSON404)
 	}
 	if res.JSON200 == nil {
 		return nil, fmt.Errorf("unexpected empty response body")
@@ -276,6 +589,8 @@ func (c *Client) TrackShipment(ctx context.Context, orderNumber string) (*Tracki
 }
 
 // TrackShipmentForCompany gets tracking information for a specific company's shipment
+//
+// Deprecated: use c.ForCompany(companyAPIKey).TrackShipment
 func (c *Client) TrackShipmentForCompany(ctx context.Context, orderNumber string, companyAPIKey string) (*Tracking, error) {
 	ctx = WithCompanyAPIKey(ctx, companyAPIKey)
 	return c.TrackShipment(ctx, orderNumber)
@@ -283,12 +598,15 @@ func (c *Client) TrackShipmentForCompany(ctx context.Context, orderNumber string
 
 // GetInvoice retrieves the invoice for a delivered shipment
 func (c *Client) GetInvoice(ctx context.Context, orderNumber string) (*Invoice, error) {
+	if err := c.preflight(ctx, orderNumber, lifecycle.CheckInvoice); err != nil {
+		return nil, err
+	}
 	res, err := c.client.GetInvoiceWithResponse(ctx, orderNumber)
 	if err != nil {
 		return nil, err
 	}
 	if res.StatusCode() != http.StatusOK {
-		return nil, fmt.Errorf("get invoice failed: status %d", res.StatusCode())
+		return nil, newAPIError("get invoice", res.HTTPResponse, res.Body, res.JSON400, res.JSON401, res.JSON403, res.JSON404)
 	}
 	if res.JSON200 == nil {
 		return nil, fmt.Errorf("unexpected empty response body")
@@ -297,6 +615,8 @@ func (c *Client) GetInvoice(ctx context.Context, orderNumber string) (*Invoice,
 }
 
 // GetInvoiceForCompany retrieves the invoice for a specific company's shipment
+//
+// Deprecated: use c.ForCompany(companyAPIKey).GetInvoice
 func (c *Client) GetInvoiceForCompany(ctx context.Context, orderNumber string, companyAPIKey string) (*Invoice, error) {
 	ctx = WithCompanyAPIKey(ctx, companyAPIKey)
 	return c.GetInvoice(ctx, orderNumber)
@@ -309,7 +629,7 @@ func (c *Client) GetShipment(ctx context.Context, orderNumber string) (*Shipment
 		return nil, err
 	}
 	if res.StatusCode() != http.StatusOK {
-		return nil, fmt.Errorf("get shipment failed: status %d", res.StatusCode())
+		return nil, newAPIError("get shipment", res.HTTPResponse, res.Body, res.JSON400, res.JSON401, res.JSON403, res.JSON404)
 	}
 	if res.JSON200 == nil {
 		return nil, fmt.Errorf("unexpected empty response body")
@@ -317,7 +637,26 @@ func (c *Client) GetShipment(ctx context.Context, orderNumber string) (*Shipment
 	return res.JSON200, nil
 }
 
+// preflight validates the shipment's current status when Config.Preflight is
+// set. Statuses the lifecycle package does not know are left for the API to
+// judge, so a status added by the server does not block every call.
+func (c *Client) preflight(ctx context.Context, orderNumber string, check func(lifecycle.Status) error) error {
+	if !c.config.Preflight {
+		return nil
+	}
+	shipment, err := c.GetShipment(ctx, orderNumber)
+	if err != nil {
+		return err
+	}
+	if shipment.OrderStatus == nil || !lifecycle.IsKnown(lifecycle.Status(*shipment.OrderStatus)) {
+		return nil
+	}
+	return check(lifecycle.Status(*shipment.OrderStatus))
+}
+
 // GetShipmentForCompany retrieves a shipment for a specific company
+//
+// Deprecated: use c.ForCompany(companyAPIKey).GetShipment
 func (c *Client) GetShipmentForCompany(ctx context.Context, orderNumber string, companyAPIKey string) (*Shipment, error) {
 	ctx = WithCompanyAPIKey(ctx, companyAPIKey)
 	return c.GetShipment(ctx, orderNumber)
@@ -325,20 +664,29 @@ func (c *Client) GetShipmentForCompany(ctx context.Context, orderNumber string,
 
 // CancelShipment cancels a shipment by order number
 func (c *Client) CancelShipment(ctx context.Context, orderNumber string) (*Shipment, error) {
-	res, err := c.client.CancelShipmentWithResponse(ctx, orderNumber)
-	if err != nil {
-		return nil, err
-	}
-	if res.StatusCode() != http.StatusOK {
-		return nil, fmt.Errorf("cancel shipment failed: status %d", res.StatusCode())
-	}
-	if res.JSON200 == nil {
-		return nil, fmt.Errorf("unexpected empty response body")
-	}
-	return res.JSON200, nil
+	// Inside the idempotent call so a replayed key returns the cached result
+	// rather than failing preflight against the status it produced
+	return c.idempotent(ctx, OperationCancelShipment, orderNumber, func(ctx context.Context) (*Shipment, error) {
+		if err := c.preflight(ctx, orderNumber, lifecycle.CheckCancel); err != nil {
+			return nil, err
+		}
+		res, err := c.client.CancelShipmentWithResponse(ctx, orderNumber)
+		if err != nil {
+			return nil, err
+		}
+		if res.StatusCode() != http.StatusOK {
+			return nil, newAPIError("cancel shipment", res.HTTPResponse, res.Body, res.JSON400, res.JSON401, res.JSON403, res.JSON404, res.JSON500)
+		}
+		if res.JSON200 == nil {
+			return nil, fmt.Errorf("unexpected empty response body")
+		}
+		return res.JSON200, nil
+	})
 }
 
 // CancelShipmentForCompany cancels a shipment for a specific company
+//
+// Deprecated: use c.ForCompany(companyAPIKey).CancelShipment
 func (c *Client) CancelShipmentForCompany(ctx context.Context, orderNumber string, companyAPIKey string) (*Shipment, error) {
 	ctx = WithCompanyAPIKey(ctx, companyAPIKey)
 	return c.CancelShipment(ctx, orderNumber)
@@ -351,7 +699,7 @@ func (c *Client) GetQuoteByID(ctx context.Context, quoteID string) (*Quote, erro
 		return nil, err
 	}
 	if res.StatusCode() != http.StatusOK {
-		return nil, fmt.Errorf("get quote failed: status %d", res.StatusCode())
+		return nil, newAPIError("get quote", res.HTTPResponse, res.Body, res.JSON400, res.JSON401, res.JSON403, res.JSON404)
 	}
 	if res.JSON200 == nil {
 		return nil, fmt.Errorf("unexpected empty response body")
@@ -360,6 +708,8 @@ func (c *Client) GetQuoteByID(ctx context.Context, quoteID string) (*Quote, erro
 }
 
 // GetQuoteByIDForCompany retrieves a quote for a specific company
+//
+// Deprecated: use c.ForCompany(companyAPIKey).GetQuoteByID
 func (c *Client) GetQuoteByIDForCompany(ctx context.Context, quoteID string, companyAPIKey string) (*Quote, error) {
 	ctx = WithCompanyAPIKey(ctx, companyAPIKey)
 	return c.GetQuoteByID(ctx, quoteID)
@@ -367,12 +717,18 @@ func (c *Client) GetQuoteByIDForCompany(ctx context.Context, quoteID string, com
 
 // GetDocument retrieves a document for a shipment by order number and document type
 func (c *Client) GetDocument(ctx context.Context, orderNumber string, documentType DocumentType) (*Document, error) {
+	err := c.preflight(ctx, orderNumber, func(s lifecycle.Status) error {
+		return lifecycle.CheckDocument(s, string(documentType))
+	})
+	if err != nil {
+		return nil, err
+	}
 	res, err := c.client.GetDocumentWithResponse(ctx, orderNumber, client.GetDocumentParamsDocumentType(documentType))
 	if err != nil {
 		return nil, err
 	}
 	if res.StatusCode() != http.StatusOK {
-		return nil, fmt.Errorf("get document failed: status %d", res.StatusCode())
+		return nil, newAPIError("get document", res.HTTPResponse, res.Body, res.JSON400, res.JSON401, res.JSON403, res.JSON404, res.JSON500)
 	}
 	if res.JSON200 == nil {
 		return nil, fmt.Errorf("unexpected empty response body")
@@ -381,6 +737,8 @@ func (c *Client) GetDocument(ctx context.Context, orderNumber string, documentTy
 }
 
 // GetDocumentForCompany retrieves a document for a specific company's shipment
+//
+// Deprecated: use c.ForCompany(companyAPIKey).GetDocument
 func (c *Client) GetDocumentForCompany(ctx context.Context, orderNumber string, documentType DocumentType, companyAPIKey string) (*Document, error) {
 	ctx = WithCompanyAPIKey(ctx, companyAPIKey)
 	return c.GetDocument(ctx, orderNumber, documentType)
@@ -2,10 +2,20 @@ package oway
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"path/filepath"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
+
+	"github.com/Oway-Inc/oway-sdk/packages/go/lifecycle"
+	"github.com/Oway-Inc/oway-sdk/packages/go/validate"
 )
 
 func TestTokenManagement(t *testing.T) {
@@ -95,3 +105,1119 @@ func TestErrorHandling(t *testing.T) {
 		})
 	}
 }
+
+func TestProblemDetailErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/v1/auth/token":
+			w.Write([]byte(`{"accessToken": "test_token", "expiresIn": 3600}`))
+		case "/v1/shipper/quote":
+			w.Header().Set("Content-Type", "application/problem+json")
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			w.Write([]byte(`{"type": "https://docs.oway.io/problems/invalid-address", "title": "Unprocessable Entity", "status": 422, "detail": "Pickup ZIP code is not serviceable", "reason": "INVALID_ADDRESS"}`))
+		default:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	client, err := New(Config{
+		ClientID:     "client_test",
+		ClientSecret: "secret_test",
+		APIKey:       "oway_sk_test_123",
+		BaseURL:      server.URL,
+		TokenURL:     server.URL + "/v1/auth/token",
+		Retry:        &RetryPolicy{MaxAttempts: 1},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("should decode problem detail", func(t *testing.T) {
+		_, err := client.RequestQuote(context.Background(), &QuoteRequest{})
+
+		var apiErr *Error
+		if !errors.As(err, &apiErr) {
+			t.Fatalf("Expected *Error, got %T: %v", err, err)
+		}
+		if apiErr.StatusCode != http.StatusUnprocessableEntity {
+			t.Errorf("Expected status 422, got %d", apiErr.StatusCode)
+		}
+		if apiErr.Reason != "INVALID_ADDRESS" || apiErr.Code != "INVALID_ADDRESS" {
+			t.Errorf("Expected reason INVALID_ADDRESS, got %q", apiErr.Reason)
+		}
+		if apiErr.Detail != "Pickup ZIP code is not serviceable" {
+			t.Errorf("Unexpected detail %q", apiErr.Detail)
+		}
+		if apiErr.RequestID == "" {
+			t.Error("Expected request ID to be populated")
+		}
+		if !apiErr.IsClientError() || apiErr.IsRetryable() {
+			t.Error("422 should be a non-retryable client error")
+		}
+	})
+
+	t.Run("should classify status without problem body", func(t *testing.T) {
+		_, err := client.TrackShipment(context.Background(), "ABC12")
+
+		var apiErr *Error
+		if !errors.As(err, &apiErr) {
+			t.Fatalf("Expected *Error, got %T: %v", err, err)
+		}
+		if apiErr.StatusCode != http.StatusServiceUnavailable || !apiErr.IsRetryable() {
+			t.Errorf("Expected retryable 503, got %d", apiErr.StatusCode)
+		}
+	})
+}
+
+func TestRetry(t *testing.T) {
+	var trackCalls, shipmentCalls atomic.Int32
+	var idempotencyKeys sync.Map
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/v1/auth/token":
+			w.Write([]byte(`{"accessToken": "test_token", "expiresIn": 3600}`))
+		case "/v1/shipper/shipment/ABC12/tracking":
+			if trackCalls.Add(1) < 3 {
+				w.Header().Set("Retry-After", "0")
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			w.Write([]byte(`{"orderNumber": "ABC12", "orderStatus": "IN_TRANSIT"}`))
+		case "/v1/shipper/shipment":
+			shipmentCalls.Add(1)
+			idempotencyKeys.Store(r.Header.Get("Idempotency-Key"), true)
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+	defer server.Close()
+
+	client, err := New(Config{
+		ClientID:     "client_test",
+		ClientSecret: "secret_test",
+		BaseURL:      server.URL,
+		TokenURL:     server.URL + "/v1/auth/token",
+		Retry:        &RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: 10 * time.Millisecond},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("should retry transient failures", func(t *testing.T) {
+		tracking, err := client.TrackShipment(context.Background(), "ABC12")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if *tracking.OrderStatus != "IN_TRANSIT" {
+			t.Errorf("Unexpected status %s", *tracking.OrderStatus)
+		}
+		if trackCalls.Load() != 3 {
+			t.Errorf("Expected 3 attempts, got %d", trackCalls.Load())
+		}
+	})
+
+	t.Run("should send a generated idempotency key without retrying", func(t *testing.T) {
+		for i := 0; i < 2; i++ {
+			if _, err := client.CreateShipment(context.Background(), &ShipmentRequest{}); err == nil {
+				t.Fatal("Expected error")
+			}
+		}
+		if shipmentCalls.Load() != 2 {
+			t.Errorf("Expected 1 attempt per call, got %d", shipmentCalls.Load())
+		}
+
+		keys := 0
+		idempotencyKeys.Range(func(key, _ any) bool {
+			if key == "" {
+				t.Error("Expected a generated Idempotency-Key header")
+			}
+			keys++
+			return true
+		})
+		if keys != 2 {
+			t.Errorf("Expected a new key per call, got %d keys", keys)
+		}
+	})
+
+	t.Run("should retry mutating operations with the caller's idempotency key", func(t *testing.T) {
+		shipmentCalls.Store(0)
+		idempotencyKeys.Clear()
+		ctx := WithIdempotencyKey(context.Background(), "booking-1")
+		if _, err := client.CreateShipment(ctx, &ShipmentRequest{}); err == nil {
+			t.Fatal("Expected error")
+		}
+		if shipmentCalls.Load() != 3 {
+			t.Errorf("Expected 3 attempts, got %d", shipmentCalls.Load())
+		}
+
+		keys := 0
+		idempotencyKeys.Range(func(key, _ any) bool {
+			if key != "booking-1" {
+				t.Errorf("Expected Idempotency-Key booking-1 on every attempt, got %q", key)
+			}
+			keys++
+			return true
+		})
+		if keys != 1 {
+			t.Errorf("Expected the same idempotency key across retries, got %d keys", keys)
+		}
+	})
+
+	t.Run("should stop when context is cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		if _, err := client.TrackShipment(ctx, "ABC12"); !errors.Is(err, context.Canceled) {
+			t.Errorf("Expected context.Canceled, got %v", err)
+		}
+	})
+}
+
+func TestAttemptTimeout(t *testing.T) {
+	var trackCalls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/v1/auth/token" {
+			w.Write([]byte(`{"accessToken": "test_token", "expiresIn": 3600}`))
+			return
+		}
+		if trackCalls.Add(1) == 1 {
+			select {
+			case <-time.After(time.Second):
+			case <-r.Context().Done():
+			}
+			return
+		}
+		w.Write([]byte(`{"orderNumber": "ABC12", "orderStatus": "IN_TRANSIT"}`))
+	}))
+	defer server.Close()
+
+	client, err := New(Config{
+		ClientID:     "client_test",
+		ClientSecret: "secret_test",
+		BaseURL:      server.URL,
+		TokenURL:     server.URL + "/v1/auth/token",
+		HTTPClient:   &http.Client{Timeout: 100 * time.Millisecond},
+		Retry:        &RetryPolicy{MaxAttempts: 3, BaseDelay: 50 * time.Millisecond},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("should retry an attempt that timed out", func(t *testing.T) {
+		tracking, err := client.TrackShipment(context.Background(), "ABC12")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if *tracking.OrderStatus != "IN_TRANSIT" {
+			t.Errorf("Unexpected status %s", *tracking.OrderStatus)
+		}
+		if trackCalls.Load() != 2 {
+			t.Errorf("Expected 2 attempts, got %d", trackCalls.Load())
+		}
+	})
+}
+
+func TestIdempotency(t *testing.T) {
+	var confirmCalls atomic.Int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/v1/auth/token":
+			w.Write([]byte(`{"accessToken": "test_token", "expiresIn": 3600}`))
+		case "/v1/shipper/shipment/ABC12/confirm":
+			confirmCalls.Add(1)
+			w.Write([]byte(`{"orderNumber": "ABC12", "orderStatus": "CONFIRMED"}`))
+		case "/v1/shipper/shipment":
+			w.Write([]byte(`{"orderNumber": "NEW01", "orderStatus": "INITIALIZED"}`))
+		}
+	}))
+	defer server.Close()
+
+	client, err := New(Config{
+		ClientID:     "client_test",
+		ClientSecret: "secret_test",
+		BaseURL:      server.URL,
+		TokenURL:     server.URL + "/v1/auth/token",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("should return first result for a repeated key", func(t *testing.T) {
+		ctx := WithIdempotencyKey(context.Background(), "confirm-ABC12")
+		for i := 0; i < 3; i++ {
+			shipment, err := client.ConfirmShipment(ctx, "ABC12")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if *shipment.OrderStatus != "CONFIRMED" {
+				t.Errorf("Unexpected status %s", *shipment.OrderStatus)
+			}
+		}
+		if confirmCalls.Load() != 1 {
+			t.Errorf("Expected 1 confirm call, got %d", confirmCalls.Load())
+		}
+	})
+
+	t.Run("should reject a key reused for another order", func(t *testing.T) {
+		initial := confirmCalls.Load()
+		ctx := WithIdempotencyKey(context.Background(), "confirm-ABC12")
+		if _, err := client.ConfirmShipment(ctx, "XYZ99"); !errors.Is(err, ErrIdempotencyKeyReused) {
+			t.Errorf("Expected ErrIdempotencyKeyReused, got %v", err)
+		}
+		if confirmCalls.Load() != initial {
+			t.Error("Expected no API call")
+		}
+	})
+
+	t.Run("should reject a key reused for another request body", func(t *testing.T) {
+		ctx := WithIdempotencyKey(context.Background(), "booking-1")
+		client.CreateShipment(ctx, &ShipmentRequest{Description: "first"})
+		if _, err := client.CreateShipment(ctx, &ShipmentRequest{Description: "second"}); !errors.Is(err, ErrIdempotencyKeyReused) {
+			t.Errorf("Expected ErrIdempotencyKeyReused, got %v", err)
+		}
+	})
+
+	t.Run("should retry a failed call once for all waiters", func(t *testing.T) {
+		cache := newIdempotencyCache(time.Minute)
+		ctx := context.Background()
+		var calls atomic.Int32
+		release := make(chan struct{})
+
+		go cache.do(ctx, "key", "ABC12", func() (*Shipment, error) {
+			calls.Add(1)
+			<-release
+			return nil, errors.New("connection reset")
+		})
+		for calls.Load() == 0 {
+			time.Sleep(time.Millisecond)
+		}
+
+		var wg sync.WaitGroup
+		for i := 0; i < 5; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, err := cache.do(ctx, "key", "ABC12", func() (*Shipment, error) {
+					calls.Add(1)
+					time.Sleep(20 * time.Millisecond)
+					return &Shipment{}, nil
+				})
+				if err != nil {
+					t.Error(err)
+				}
+			}()
+		}
+		time.Sleep(20 * time.Millisecond)
+		close(release)
+		wg.Wait()
+
+		if calls.Load() != 2 {
+			t.Errorf("Expected 2 calls, got %d", calls.Load())
+		}
+	})
+
+	t.Run("should issue a new call without a key", func(t *testing.T) {
+		initial := confirmCalls.Load()
+		client.ConfirmShipment(context.Background(), "ABC12")
+		client.ConfirmShipment(context.Background(), "ABC12")
+		if confirmCalls.Load()-initial != 2 {
+			t.Errorf("Expected 2 confirm calls, got %d", confirmCalls.Load()-initial)
+		}
+	})
+}
+
+func TestRequestID(t *testing.T) {
+	var mu sync.Mutex
+	var seen []string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/v1/auth/token" {
+			w.Write([]byte(`{"accessToken": "test_token", "expiresIn": 3600}`))
+			return
+		}
+		mu.Lock()
+		seen = append(seen, r.Header.Get("x-request-id"))
+		mu.Unlock()
+		w.Header().Set("x-request-id", "srv-1")
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	client, err := New(Config{
+		ClientID:     "client_test",
+		ClientSecret: "secret_test",
+		BaseURL:      server.URL,
+		TokenURL:     server.URL + "/v1/auth/token",
+		Retry:        &RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := WithRequestID(context.Background(), "trace-123")
+	_, err = client.TrackShipment(ctx, "ABC12")
+
+	var apiErr *Error
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("Expected *Error, got %T: %v", err, err)
+	}
+	if apiErr.RequestID != "trace-123" {
+		t.Errorf("Expected request ID trace-123, got %q", apiErr.RequestID)
+	}
+	if apiErr.ServerRequestID != "srv-1" {
+		t.Errorf("Expected server request ID srv-1, got %q", apiErr.ServerRequestID)
+	}
+
+	if len(seen) != 3 {
+		t.Fatalf("Expected 3 attempts, got %d", len(seen))
+	}
+	for _, id := range seen {
+		if id != "trace-123" {
+			t.Errorf("Expected the same request ID on every attempt, got %q", id)
+		}
+	}
+}
+
+func TestCaptureRequestIDs(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/v1/auth/token" {
+			w.Write([]byte(`{"accessToken": "test_token", "expiresIn": 3600}`))
+			return
+		}
+		w.Header().Set("x-request-id", "srv-"+r.Header.Get("x-request-id"))
+		w.Write([]byte(`{"orderNumber": "ABC12", "orderStatus": "IN_TRANSIT"}`))
+	}))
+	defer server.Close()
+
+	client, err := New(Config{
+		ClientID:     "client_test",
+		ClientSecret: "secret_test",
+		BaseURL:      server.URL,
+		TokenURL:     server.URL + "/v1/auth/token",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("should report the sent and server request IDs on success", func(t *testing.T) {
+		var ids RequestIDs
+		ctx := CaptureRequestIDs(WithRequestID(context.Background(), "trace-123"), &ids)
+		if _, err := client.TrackShipment(ctx, "ABC12"); err != nil {
+			t.Fatal(err)
+		}
+		if ids.Sent != "trace-123" {
+			t.Errorf("Expected sent request ID trace-123, got %q", ids.Sent)
+		}
+		if ids.Server != "srv-trace-123" {
+			t.Errorf("Expected server request ID srv-trace-123, got %q", ids.Server)
+		}
+	})
+
+	t.Run("should report a generated request ID", func(t *testing.T) {
+		var ids RequestIDs
+		if _, err := client.TrackShipment(CaptureRequestIDs(context.Background(), &ids), "ABC12"); err != nil {
+			t.Fatal(err)
+		}
+		if ids.Sent == "" || ids.Server != "srv-"+ids.Sent {
+			t.Errorf("Expected a generated request ID echoed by the server, got %+v", ids)
+		}
+	})
+
+	t.Run("should return a RequestError for transport errors", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(WithRequestID(context.Background(), "trace-456"))
+		cancel()
+		_, err := client.TrackShipment(ctx, "ABC12")
+
+		var reqErr *RequestError
+		if !errors.As(err, &reqErr) {
+			t.Fatalf("Expected *RequestError, got %T: %v", err, err)
+		}
+		if reqErr.RequestID != "trace-456" {
+			t.Errorf("Expected request ID trace-456, got %q", reqErr.RequestID)
+		}
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("Expected the error to wrap context.Canceled, got %v", err)
+		}
+	})
+}
+
+func TestCarrierMethods(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/v1/auth/token":
+			w.Write([]byte(`{"accessToken": "test_token", "expiresIn": 3600}`))
+		case "/v1/carrier/carrier_1/gps-data":
+			if r.Header.Get("x-oway-api-key") != "oway_ck_test_123" {
+				w.WriteHeader(http.StatusForbidden)
+				w.Write([]byte(`{"title": "Forbidden", "status": 403, "detail": "Carrier API key required"}`))
+				return
+			}
+			w.Write([]byte(`2`))
+		case "/v1/carrier/carrier_1/jobs":
+			if r.URL.Query().Get("activeOnly") != "true" {
+				t.Errorf("Expected activeOnly=true, got %q", r.URL.RawQuery)
+			}
+			w.Write([]byte(`[{"id": "offer_1", "orderId": "order_1"}]`))
+		}
+	}))
+	defer server.Close()
+
+	client, err := New(Config{
+		ClientID:      "client_test",
+		ClientSecret:  "secret_test",
+		APIKey:        "oway_sk_test_123",
+		CarrierAPIKey: "oway_ck_test_123",
+		BaseURL:       server.URL,
+		TokenURL:      server.URL + "/v1/auth/token",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	points := []GpsData{
+		{VehicleId: "truck_1", Latitude: 34.05, Longitude: -118.24, Timestamp: time.Now()},
+		{VehicleId: "truck_2", Latitude: 40.71, Longitude: -74.00, Timestamp: time.Now()},
+	}
+
+	t.Run("should add gps data with carrier key", func(t *testing.T) {
+		added, err := client.AddGpsDataForCompany(ctx, "carrier_1", points, "oway_ck_test_123")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if added != 2 {
+			t.Errorf("Expected 2 points added, got %d", added)
+		}
+	})
+
+	t.Run("should reject shipper key locally", func(t *testing.T) {
+		_, err := client.AddGpsDataForCompany(ctx, "carrier_1", points, "oway_sk_test_123")
+		if !errors.Is(err, ErrAPIKeyType) {
+			t.Fatalf("Expected ErrAPIKeyType, got %v", err)
+		}
+	})
+
+	t.Run("should use default carrier key", func(t *testing.T) {
+		if _, err := client.AddGpsData(ctx, "carrier_1", points); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("should get jobs", func(t *testing.T) {
+		activeOnly := true
+		jobs, err := client.GetJobs(ctx, "carrier_1", &GetJobsParams{ActiveOnly: &activeOnly})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(jobs) != 1 || *jobs[0].Id != "offer_1" {
+			t.Errorf("Unexpected jobs %+v", jobs)
+		}
+	})
+}
+
+func TestPreflight(t *testing.T) {
+	var confirmCalls atomic.Int32
+	var mu sync.Mutex
+	statuses := map[string]string{"ABC12": "PICKED_UP", "NEW01": "INITIALIZED", "ODD01": "ON_HOLD"}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/v1/auth/token" {
+			w.Write([]byte(`{"accessToken": "test_token", "expiresIn": 3600}`))
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		orderNumber, confirm := strings.CutSuffix(strings.TrimPrefix(r.URL.Path, "/v1/shipper/shipment/"), "/confirm")
+		if confirm {
+			confirmCalls.Add(1)
+			if statuses[orderNumber] == "PICKED_UP" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			statuses[orderNumber] = "CONFIRMED"
+		}
+		fmt.Fprintf(w, `{"orderNumber": %q, "orderStatus": %q}`, orderNumber, statuses[orderNumber])
+	}))
+	defer server.Close()
+
+	client, err := New(Config{
+		ClientID:     "client_test",
+		ClientSecret: "secret_test",
+		BaseURL:      server.URL,
+		TokenURL:     server.URL + "/v1/auth/token",
+		Preflight:    true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("should reject a call invalid in the current status", func(t *testing.T) {
+		_, err := client.ConfirmShipment(context.Background(), "ABC12")
+		var stateErr *lifecycle.StateError
+		if !errors.As(err, &stateErr) || stateErr.Status != lifecycle.PickedUp {
+			t.Fatalf("Expected *lifecycle.StateError for PICKED_UP, got %v", err)
+		}
+		if confirmCalls.Load() != 0 {
+			t.Errorf("Expected confirm not to be sent, got %d calls", confirmCalls.Load())
+		}
+	})
+
+	t.Run("should return the cached result for a replayed idempotency key", func(t *testing.T) {
+		ctx := WithIdempotencyKey(context.Background(), "confirm-NEW01")
+		for i := 0; i < 2; i++ {
+			shipment, err := client.ConfirmShipment(ctx, "NEW01")
+			if err != nil {
+				t.Fatalf("Attempt %d: %v", i+1, err)
+			}
+			if *shipment.OrderStatus != "CONFIRMED" {
+				t.Errorf("Unexpected status %s", *shipment.OrderStatus)
+			}
+		}
+		if confirmCalls.Load() != 1 {
+			t.Errorf("Expected 1 confirm call, got %d", confirmCalls.Load())
+		}
+	})
+
+	t.Run("should let unknown statuses through to the API", func(t *testing.T) {
+		if _, err := client.ConfirmShipment(context.Background(), "ODD01"); err != nil {
+			t.Errorf("Expected unknown status to pass preflight, got %v", err)
+		}
+	})
+}
+
+func TestTokenRefresh(t *testing.T) {
+	var tokenCalls, expiresIn atomic.Int32
+	var gate sync.RWMutex
+
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gate.RLock()
+		defer gate.RUnlock()
+		n := tokenCalls.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"accessToken": "token_%d", "expiresIn": %d}`, n, expiresIn.Load())
+	}))
+	defer tokenServer.Close()
+
+	newClient := func(fraction float64) *Client {
+		client, err := New(Config{
+			ClientID:             "client_test",
+			ClientSecret:         "secret_test",
+			TokenURL:             tokenServer.URL,
+			TokenRefreshFraction: fraction,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return client
+	}
+
+	t.Run("should coalesce concurrent refreshes", func(t *testing.T) {
+		tokenCalls.Store(0)
+		expiresIn.Store(3600)
+
+		// Hold the token endpoint until every caller is waiting
+		gate.Lock()
+		client := newClient(0.8)
+		var wg sync.WaitGroup
+		for i := 0; i < 20; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if _, err := client.getAccessToken(context.Background()); err != nil {
+					t.Error(err)
+				}
+			}()
+		}
+		time.Sleep(20 * time.Millisecond)
+		gate.Unlock()
+		wg.Wait()
+
+		if tokenCalls.Load() != 1 {
+			t.Errorf("Expected 1 token call, got %d", tokenCalls.Load())
+		}
+	})
+
+	t.Run("should refresh in background with Start", func(t *testing.T) {
+		tokenCalls.Store(0)
+		expiresIn.Store(1)
+
+		// A 1s token refreshed at 5% of its lifetime renews every 50ms
+		client := newClient(0.05)
+		if err := client.Start(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		if err := client.Start(context.Background()); err == nil {
+			t.Error("Expected error starting twice")
+		}
+
+		time.Sleep(300 * time.Millisecond)
+		client.Stop()
+		calls := tokenCalls.Load()
+		if calls < 3 {
+			t.Errorf("Expected at least 3 background refreshes, got %d", calls)
+		}
+
+		time.Sleep(150 * time.Millisecond)
+		if tokenCalls.Load() != calls {
+			t.Errorf("Expected no refreshes after Stop, got %d more", tokenCalls.Load()-calls)
+		}
+	})
+}
+
+func TestTokenStore(t *testing.T) {
+	var tokenCalls atomic.Int32
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := tokenCalls.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"accessToken": "token_%d", "expiresIn": 3600}`, n)
+	}))
+	defer tokenServer.Close()
+
+	newClient := func(store TokenStore) *Client {
+		client, err := New(Config{
+			ClientID:     "client_test",
+			ClientSecret: "secret_test",
+			TokenURL:     tokenServer.URL,
+			TokenStore:   store,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return client
+	}
+
+	t.Run("should share token between clients", func(t *testing.T) {
+		tokenCalls.Store(0)
+		store := NewFileTokenStore(filepath.Join(t.TempDir(), "tokens", "oway.json"))
+
+		first, err := newClient(store).getAccessToken(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		second, err := newClient(store).getAccessToken(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if first != second {
+			t.Errorf("Expected shared token %q, got %q", first, second)
+		}
+		if tokenCalls.Load() != 1 {
+			t.Errorf("Expected 1 token call, got %d", tokenCalls.Load())
+		}
+	})
+
+	t.Run("should make one token request for concurrent clients sharing a file", func(t *testing.T) {
+		tokenCalls.Store(0)
+		path := filepath.Join(t.TempDir(), "oway.json")
+
+		// Each client has its own store, as separate processes would
+		const clients = 8
+		tokens := make([]string, clients)
+		var wg sync.WaitGroup
+		for i := range clients {
+			client := newClient(NewFileTokenStore(path))
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				token, err := client.getAccessToken(context.Background())
+				if err != nil {
+					t.Error(err)
+				}
+				tokens[i] = token
+			}()
+		}
+		wg.Wait()
+
+		if tokenCalls.Load() != 1 {
+			t.Errorf("Expected 1 token call, got %d", tokenCalls.Load())
+		}
+		for _, token := range tokens {
+			if token != tokens[0] {
+				t.Errorf("Expected shared token %q, got %q", tokens[0], token)
+			}
+		}
+	})
+
+	t.Run("should ignore expired stored token", func(t *testing.T) {
+		tokenCalls.Store(0)
+		store := NewMemoryTokenStore()
+		store.Set(context.Background(), "client_test@"+tokenServer.URL, "stale", time.Now().Add(-time.Minute))
+
+		token, err := newClient(store).getAccessToken(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if token == "stale" || tokenCalls.Load() != 1 {
+			t.Errorf("Expected a fresh token, got %q after %d calls", token, tokenCalls.Load())
+		}
+		if stored, _, _ := store.Get(context.Background(), "client_test@"+tokenServer.URL); stored != token {
+			t.Errorf("Expected store to hold %q, got %q", token, stored)
+		}
+	})
+}
+
+func TestTokenErrors(t *testing.T) {
+	var tokenCalls atomic.Int32
+	var respond func(w http.ResponseWriter, n int32)
+
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		respond(w, tokenCalls.Add(1))
+	}))
+	defer tokenServer.Close()
+
+	newClient := func() *Client {
+		client, err := New(Config{
+			ClientID:     "client_test",
+			ClientSecret: "secret_test",
+			TokenURL:     tokenServer.URL,
+			Retry:        &RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return client
+	}
+
+	t.Run("should return AuthError for invalid credentials without retrying", func(t *testing.T) {
+		tokenCalls.Store(0)
+		respond = func(w http.ResponseWriter, n int32) {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"error": "invalid_client", "errorDescription": "unknown client"}`))
+		}
+
+		_, err := newClient().getAccessToken(context.Background())
+		var authErr *AuthError
+		if !errors.As(err, &authErr) {
+			t.Fatalf("Expected *AuthError, got %v", err)
+		}
+		if !authErr.IsInvalidCredentials() || authErr.Code != "invalid_client" || authErr.Description != "unknown client" {
+			t.Errorf("Unexpected AuthError: %+v", authErr)
+		}
+		if tokenCalls.Load() != 1 {
+			t.Errorf("Expected 1 token call, got %d", tokenCalls.Load())
+		}
+	})
+
+	t.Run("should retry server failures", func(t *testing.T) {
+		tokenCalls.Store(0)
+		respond = func(w http.ResponseWriter, n int32) {
+			if n < 3 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			w.Write([]byte(`{"accessToken": "test_token", "expiresIn": 3600}`))
+		}
+
+		if _, err := newClient().getAccessToken(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		if tokenCalls.Load() != 3 {
+			t.Errorf("Expected 3 token calls, got %d", tokenCalls.Load())
+		}
+	})
+
+	t.Run("should reject response without accessToken", func(t *testing.T) {
+		tokenCalls.Store(0)
+		respond = func(w http.ResponseWriter, n int32) {
+			w.Write([]byte(`{"expiresIn": 3600}`))
+		}
+
+		_, err := newClient().getAccessToken(context.Background())
+		var authErr *AuthError
+		if !errors.As(err, &authErr) || !authErr.IsServerError() {
+			t.Errorf("Expected server-side AuthError, got %v", err)
+		}
+	})
+
+	t.Run("should default TTL when expiresIn is missing", func(t *testing.T) {
+		tokenCalls.Store(0)
+		respond = func(w http.ResponseWriter, n int32) {
+			w.Write([]byte(`{"accessToken": "test_token"}`))
+		}
+
+		client := newClient()
+		for i := 0; i < 3; i++ {
+			if _, err := client.getAccessToken(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if tokenCalls.Load() != 1 {
+			t.Errorf("Expected token to be cached, got %d token calls", tokenCalls.Load())
+		}
+	})
+}
+
+func TestUnauthorizedRetry(t *testing.T) {
+	var tokenCalls, quoteCalls atomic.Int32
+	var validToken atomic.Value
+	var bodies sync.Map
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/v1/auth/token":
+			fmt.Fprintf(w, `{"accessToken": "token_%d", "expiresIn": 3600}`, tokenCalls.Add(1))
+		case "/v1/shipper/quote":
+			body, _ := io.ReadAll(r.Body)
+			bodies.Store(quoteCalls.Add(1), string(body))
+			if r.Header.Get("Authorization") != "Bearer "+validToken.Load().(string) {
+				w.WriteHeader(http.StatusUnauthorized)
+				w.Write([]byte(`{"title": "Unauthorized", "detail": "token revoked", "reason": "INVALID_TOKEN"}`))
+				return
+			}
+			w.Write([]byte(`{"quoteId": "quote_1"}`))
+		}
+	}))
+	defer server.Close()
+
+	client, err := New(Config{
+		ClientID:     "client_test",
+		ClientSecret: "secret_test",
+		BaseURL:      server.URL,
+		TokenURL:     server.URL + "/v1/auth/token",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := &QuoteRequest{}
+
+	t.Run("should refresh token and replay once", func(t *testing.T) {
+		// The server rotates to token_2 before token_1 expires locally
+		validToken.Store("token_2")
+		if _, err := client.RequestQuote(context.Background(), req); err != nil {
+			t.Fatal(err)
+		}
+		if tokenCalls.Load() != 2 || quoteCalls.Load() != 2 {
+			t.Errorf("Expected 2 token and 2 quote calls, got %d and %d", tokenCalls.Load(), quoteCalls.Load())
+		}
+
+		first, _ := bodies.Load(int32(1))
+		second, _ := bodies.Load(int32(2))
+		if first == "" || first != second {
+			t.Errorf("Expected replayed body %q, got %q", first, second)
+		}
+	})
+
+	t.Run("should report persistent rejection", func(t *testing.T) {
+		tokenCalls.Store(0)
+		quoteCalls.Store(0)
+		validToken.Store("never")
+
+		_, err := client.RequestQuote(context.Background(), req)
+		if !errors.Is(err, ErrTokenRejected) {
+			t.Fatalf("Expected ErrTokenRejected, got %v", err)
+		}
+		var apiErr *Error
+		if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusUnauthorized || apiErr.Reason != "INVALID_TOKEN" {
+			t.Errorf("Expected wrapped 401 *Error, got %v", err)
+		}
+		if tokenCalls.Load() != 1 || quoteCalls.Load() != 2 {
+			t.Errorf("Expected 1 token and 2 quote calls, got %d and %d", tokenCalls.Load(), quoteCalls.Load())
+		}
+	})
+}
+
+func TestAPIKeyValidation(t *testing.T) {
+	tests := []struct {
+		operation string
+		apiKey    string
+		valid     bool
+	}{
+		{OperationRequestQuote, "oway_sk_test_123", true},
+		{OperationRequestQuote, "oway_ck_test_123", false},
+		{OperationAddGpsData, "oway_ck_test_123", true},
+		{OperationAddGpsData, "oway_sk_test_123", false},
+		{OperationGetJobs, "not_a_key", false},
+		{OperationGetShipment, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.operation+" "+tt.apiKey, func(t *testing.T) {
+			err := validateAPIKey(tt.operation, tt.apiKey)
+			if (err == nil) != tt.valid {
+				t.Errorf("Expected valid=%v, got %v", tt.valid, err)
+			}
+		})
+	}
+
+	t.Run("should redact keys", func(t *testing.T) {
+		if got := redactAPIKey("oway_sk_live_abcdef123456"); got != "oway_sk_live_****3456" {
+			t.Errorf("Unexpected redaction %q", got)
+		}
+		if got := redactAPIKey("short"); got != "****" {
+			t.Errorf("Unexpected redaction %q", got)
+		}
+	})
+}
+
+func TestForCompany(t *testing.T) {
+	var tokenCalls atomic.Int32
+	var apiKeys sync.Map
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/v1/auth/token":
+			tokenCalls.Add(1)
+			w.Write([]byte(`{"accessToken": "test_token", "expiresIn": 3600}`))
+		case "/v1/carrier/carrier_1/jobs":
+			apiKeys.Store("carrier", r.Header.Get("x-oway-api-key"))
+			w.Write([]byte(`[]`))
+		default:
+			apiKeys.Store("shipper", r.Header.Get("x-oway-api-key"))
+			w.Write([]byte(`{"orderNumber": "ABC12", "orderStatus": "IN_TRANSIT"}`))
+		}
+	}))
+	defer server.Close()
+
+	client, err := New(Config{
+		ClientID:     "client_test",
+		ClientSecret: "secret_test",
+		APIKey:       "oway_sk_default",
+		BaseURL:      server.URL,
+		TokenURL:     server.URL + "/v1/auth/token",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	t.Run("should pin the API key for shipper and carrier calls", func(t *testing.T) {
+		if _, err := client.ForCompany("oway_sk_acme").TrackShipment(ctx, "ABC12"); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := client.ForCompany("oway_ck_fleet").GetJobs(ctx, "carrier_1", nil); err != nil {
+			t.Fatal(err)
+		}
+
+		if key, _ := apiKeys.Load("shipper"); key != "oway_sk_acme" {
+			t.Errorf("Expected pinned shipper key, got %v", key)
+		}
+		if key, _ := apiKeys.Load("carrier"); key != "oway_ck_fleet" {
+			t.Errorf("Expected pinned carrier key, got %v", key)
+		}
+	})
+
+	t.Run("should leave the parent client unchanged", func(t *testing.T) {
+		if _, err := client.TrackShipment(ctx, "ABC12"); err != nil {
+			t.Fatal(err)
+		}
+		if key, _ := apiKeys.Load("shipper"); key != "oway_sk_default" {
+			t.Errorf("Expected default key, got %v", key)
+		}
+	})
+
+	t.Run("should share the token cache", func(t *testing.T) {
+		if tokenCalls.Load() != 1 {
+			t.Errorf("Expected 1 token call across views, got %d", tokenCalls.Load())
+		}
+	})
+}
+
+func TestValidateRequests(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	client, err := New(Config{
+		ClientID:         "client_test",
+		ClientSecret:     "secret_test",
+		APIKey:           "oway_sk_test_abcdef123456",
+		BaseURL:          server.URL,
+		TokenURL:         server.URL + "/v1/auth/token",
+		ValidateRequests: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("should reject invalid requests without calling the API", func(t *testing.T) {
+		_, err := client.RequestQuote(context.Background(), &QuoteRequest{})
+		var verr *validate.ValidationError
+		if !errors.As(err, &verr) || len(verr.Fields) < 2 {
+			t.Errorf("Expected a multi-field *validate.ValidationError, got %v", err)
+		}
+		_, err = client.ForCompany("oway_sk_test_other123456").CreateShipment(context.Background(), &ShipmentRequest{})
+		if !errors.Is(err, validate.ErrInvalid) {
+			t.Errorf("Expected validate.ErrInvalid, got %v", err)
+		}
+		if calls.Load() != 0 {
+			t.Errorf("Expected no API calls, got %d", calls.Load())
+		}
+	})
+}
+
+func TestTokenFailureRetries(t *testing.T) {
+	var tokenCalls, trackCalls atomic.Int32
+	var tokenStatus atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/v1/auth/token":
+			tokenCalls.Add(1)
+			w.WriteHeader(int(tokenStatus.Load()))
+			w.Write([]byte(`{"error": "invalid_client", "error_description": "bad secret"}`))
+		default:
+			trackCalls.Add(1)
+			w.Write([]byte(`{"orderNumber": "ORD-123"}`))
+		}
+	}))
+	defer server.Close()
+
+	newClient := func() *Client {
+		client, err := New(Config{
+			ClientID:     "client_test",
+			ClientSecret: "wrong_secret",
+			APIKey:       "oway_sk_test_abcdef123456",
+			BaseURL:      server.URL,
+			TokenURL:     server.URL + "/v1/auth/token",
+			Retry:        &RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return client
+	}
+
+	t.Run("should not retry invalid credentials", func(t *testing.T) {
+		tokenCalls.Store(0)
+		tokenStatus.Store(http.StatusUnauthorized)
+		_, err := newClient().TrackShipment(context.Background(), "ORD-123")
+		var authErr *AuthError
+		if !errors.As(err, &authErr) || !authErr.IsInvalidCredentials() {
+			t.Fatalf("Expected invalid credentials *AuthError, got %v", err)
+		}
+		if tokenCalls.Load() != 1 {
+			t.Errorf("Expected 1 token request, got %d", tokenCalls.Load())
+		}
+	})
+
+	t.Run("should retry token server errors only under the token policy", func(t *testing.T) {
+		tokenCalls.Store(0)
+		tokenStatus.Store(http.StatusServiceUnavailable)
+		if _, err := newClient().TrackShipment(context.Background(), "ORD-123"); err == nil {
+			t.Fatal("Expected an error")
+		}
+		if tokenCalls.Load() != 3 {
+			t.Errorf("Expected 3 token requests, got %d", tokenCalls.Load())
+		}
+		if trackCalls.Load() != 0 {
+			t.Errorf("Expected no API calls, got %d", trackCalls.Load())
+		}
+	})
+}
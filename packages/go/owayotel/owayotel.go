@@ -0,0 +1,225 @@
+// Package owayotel instruments the Oway client with OpenTelemetry. It is a
+// separate module so the core SDK does not depend on OpenTelemetry.
+//
+//	client, err := oway.New(oway.Config{
+//		// ...
+//		Instrumentation: owayotel.New(),
+//	})
+//
+// Every API call becomes a client span named after its operationId, with
+// trace context propagated to the API in request headers. The following
+// metrics are recorded:
+//
+//   - oway.client.call.duration: call latency in seconds, including retries
+//   - oway.client.call.errors: failed calls by error code
+//   - oway.client.call.retries: retried attempts
+//   - oway.client.token.refreshes: token endpoint requests by outcome
+package owayotel
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+
+	oway "github.com/Oway-Inc/oway-sdk/packages/go"
+)
+
+// instrumentationName identifies the SDK's tracer and meter
+const instrumentationName = "github.com/Oway-Inc/oway-sdk/packages/go"
+
+// Span and metric attributes
+const (
+	attrOperation   = attribute.Key("oway.operation")
+	attrOrderNumber = attribute.Key("oway.order_number")
+	attrRequestID   = attribute.Key("oway.request_id")
+	attrAttempts    = attribute.Key("oway.attempts")
+	attrMethod      = attribute.Key("http.request.method")
+	attrPath        = attribute.Key("url.path")
+	attrStatusCode  = attribute.Key("http.response.status_code")
+	attrErrorType   = attribute.Key("error.type")
+	attrOutcome     = attribute.Key("oway.outcome")
+)
+
+// Option configures New
+type Option func(*options)
+
+type options struct {
+	tracerProvider trace.TracerProvider
+	meterProvider  metric.MeterProvider
+	propagator     propagation.TextMapPropagator
+}
+
+// WithTracerProvider sets the tracer provider (default: otel.GetTracerProvider())
+func WithTracerProvider(provider trace.TracerProvider) Option {
+	return func(o *options) { o.tracerProvider = provider }
+}
+
+// WithMeterProvider sets the meter provider (default: otel.GetMeterProvider())
+func WithMeterProvider(provider metric.MeterProvider) Option {
+	return func(o *options) { o.meterProvider = provider }
+}
+
+// WithPropagator sets the propagator that injects trace context into
+// requests (default: otel.GetTextMapPropagator())
+func WithPropagator(propagator propagation.TextMapPropagator) Option {
+	return func(o *options) { o.propagator = propagator }
+}
+
+type instrumentation struct {
+	tracer     trace.Tracer
+	propagator propagation.TextMapPropagator
+
+	duration  metric.Float64Histogram
+	errors    metric.Int64Counter
+	retries   metric.Int64Counter
+	refreshes metric.Int64Counter
+}
+
+// New returns hooks for oway.Config.Instrumentation that record traces and
+// metrics with OpenTelemetry
+func New(opts ...Option) *oway.Instrumentation {
+	o := options{
+		tracerProvider: otel.GetTracerProvider(),
+		meterProvider:  otel.GetMeterProvider(),
+		propagator:     otel.GetTextMapPropagator(),
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	meter := o.meterProvider.Meter(instrumentationName)
+	i := &instrumentation{
+		tracer:     o.tracerProvider.Tracer(instrumentationName),
+		propagator: o.propagator,
+	}
+	// Instrument creation only fails on invalid names; a no-op instrument is
+	// returned alongside the error, so metrics degrade rather than calls
+	var err error
+	i.duration, err = meter.Float64Histogram("oway.client.call.duration",
+		metric.WithDescription("Duration of Oway API calls, including retries"),
+		metric.WithUnit("s"))
+	otel.Handle(err)
+	i.errors, err = meter.Int64Counter("oway.client.call.errors",
+		metric.WithDescription("Failed Oway API calls by error code"),
+		metric.WithUnit("{call}"))
+	otel.Handle(err)
+	i.retries, err = meter.Int64Counter("oway.client.call.retries",
+		metric.WithDescription("Retried Oway API call attempts"),
+		metric.WithUnit("{attempt}"))
+	otel.Handle(err)
+	i.refreshes, err = meter.Int64Counter("oway.client.token.refreshes",
+		metric.WithDescription("Oway access token requests by outcome"),
+		metric.WithUnit("{request}"))
+	otel.Handle(err)
+
+	return &oway.Instrumentation{
+		StartCall:      i.startCall,
+		EndCall:        i.endCall,
+		InjectHeaders:  i.injectHeaders,
+		OnRetry:        i.onRetry,
+		OnTokenRefresh: i.onTokenRefresh,
+	}
+}
+
+// spanName names the span of a call after its operationId
+func spanName(call oway.CallInfo) string {
+	if call.Operation != "" {
+		return call.Operation
+	}
+	return call.Method
+}
+
+func (i *instrumentation) startCall(ctx context.Context, call oway.CallInfo) context.Context {
+	attrs := []attribute.KeyValue{
+		attrOperation.String(call.Operation),
+		attrMethod.String(call.Method),
+		attrPath.String(call.Path),
+		attrRequestID.String(call.RequestID),
+	}
+	if call.OrderNumber != "" {
+		attrs = append(attrs, attrOrderNumber.String(call.OrderNumber))
+	}
+	ctx, _ = i.tracer.Start(ctx, spanName(call), trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attrs...))
+	return ctx
+}
+
+func (i *instrumentation) endCall(ctx context.Context, call oway.CallInfo, result oway.CallResult) {
+	span := trace.SpanFromContext(ctx)
+	span.SetAttributes(attrAttempts.Int(result.Attempts))
+	if result.StatusCode != 0 {
+		span.SetAttributes(attrStatusCode.Int(result.StatusCode))
+	}
+
+	metricAttrs := []attribute.KeyValue{attrOperation.String(call.Operation)}
+	if result.StatusCode != 0 {
+		metricAttrs = append(metricAttrs, attrStatusCode.Int(result.StatusCode))
+	}
+	i.duration.Record(ctx, result.Duration.Seconds(), metric.WithAttributes(metricAttrs...))
+
+	if code := errorCode(result); code != "" {
+		span.SetAttributes(attrErrorType.String(code))
+		if result.Err != nil {
+			span.RecordError(result.Err)
+			span.SetStatus(codes.Error, result.Err.Error())
+		} else {
+			span.SetStatus(codes.Error, http.StatusText(result.StatusCode))
+		}
+		i.errors.Add(ctx, 1, metric.WithAttributes(attrOperation.String(call.Operation), attrErrorType.String(code)))
+	}
+	span.End()
+}
+
+// errorCode classifies a failed call as its HTTP status code or a kind of
+// client-side error; it is empty for successful calls
+func errorCode(result oway.CallResult) string {
+	switch {
+	case result.Err == nil && result.StatusCode < 400:
+		return ""
+	case result.Err == nil:
+		return strconv.Itoa(result.StatusCode)
+	case errors.Is(result.Err, oway.ErrTokenRejected):
+		return "token_rejected"
+	case errors.Is(result.Err, oway.ErrCircuitOpen):
+		return "circuit_open"
+	case errors.Is(result.Err, oway.ErrAPIKeyType):
+		return "api_key_type"
+	case errors.Is(result.Err, context.Canceled):
+		return "canceled"
+	case errors.Is(result.Err, context.DeadlineExceeded):
+		return "timeout"
+	}
+	var authErr *oway.AuthError
+	if errors.As(result.Err, &authErr) {
+		return "auth"
+	}
+	return "transport"
+}
+
+func (i *instrumentation) injectHeaders(ctx context.Context, header http.Header) {
+	i.propagator.Inject(ctx, propagation.HeaderCarrier(header))
+}
+
+func (i *instrumentation) onRetry(ctx context.Context, call oway.CallInfo, attempt int, delay time.Duration) {
+	trace.SpanFromContext(ctx).AddEvent("retry", trace.WithAttributes(
+		attribute.Int("oway.attempt", attempt),
+		attribute.Float64("oway.retry_delay", delay.Seconds()),
+	))
+	i.retries.Add(ctx, 1, metric.WithAttributes(attrOperation.String(call.Operation)))
+}
+
+func (i *instrumentation) onTokenRefresh(ctx context.Context, duration time.Duration, err error) {
+	outcome := "success"
+	if err != nil {
+		outcome = "failure"
+	}
+	i.refreshes.Add(ctx, 1, metric.WithAttributes(attrOutcome.String(outcome)))
+}
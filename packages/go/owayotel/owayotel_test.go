@@ -0,0 +1,149 @@
+package owayotel
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	oway "github.com/Oway-Inc/oway-sdk/packages/go"
+)
+
+func TestInstrumentation(t *testing.T) {
+	var trackCalls atomic.Int32
+	var traceparent atomic.Value
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/v1/auth/token":
+			w.Write([]byte(`{"accessToken": "test_token", "expiresIn": 3600}`))
+		case "/v1/shipper/shipment/ORD-123/tracking":
+			traceparent.Store(r.Header.Get("traceparent"))
+			if trackCalls.Add(1) == 1 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			w.Write([]byte(`{"orderNumber": "ORD-123", "status": "IN_TRANSIT"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"title": "Not Found"}`))
+		}
+	}))
+	defer server.Close()
+
+	spans := tracetest.NewSpanRecorder()
+	reader := sdkmetric.NewManualReader()
+	client, err := oway.New(oway.Config{
+		ClientID:     "client_test",
+		ClientSecret: "secret_test",
+		APIKey:       "oway_sk_test_abcdef123456",
+		BaseURL:      server.URL,
+		TokenURL:     server.URL + "/v1/auth/token",
+		Retry:        &oway.RetryPolicy{MaxAttempts: 2, BaseDelay: time.Millisecond},
+		Instrumentation: New(
+			WithTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spans))),
+			WithMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))),
+			WithPropagator(propagation.TraceContext{}),
+		),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := client.TrackShipment(context.Background(), "ORD-123"); err != nil {
+		t.Fatal(err)
+	}
+	client.GetShipment(context.Background(), "ORD-404")
+
+	ended := spans.Ended()
+	if len(ended) != 2 {
+		t.Fatalf("Expected 2 spans, got %d", len(ended))
+	}
+
+	t.Run("should name spans after the operationId", func(t *testing.T) {
+		if ended[0].Name() != oway.OperationTrackShipment {
+			t.Errorf("Expected span %s, got %s", oway.OperationTrackShipment, ended[0].Name())
+		}
+		if ended[1].Name() != oway.OperationGetShipment {
+			t.Errorf("Expected span %s, got %s", oway.OperationGetShipment, ended[1].Name())
+		}
+	})
+
+	t.Run("should record call attributes", func(t *testing.T) {
+		attrs := attribute.NewSet(ended[0].Attributes()...)
+		for key, want := range map[attribute.Key]attribute.Value{
+			attrOrderNumber: attribute.StringValue("ORD-123"),
+			attrStatusCode:  attribute.IntValue(200),
+			attrAttempts:    attribute.IntValue(2),
+		} {
+			if got, _ := attrs.Value(key); got != want {
+				t.Errorf("Expected %s=%v, got %v", key, want.Emit(), got.Emit())
+			}
+		}
+		if len(ended[0].Events()) != 1 || ended[0].Events()[0].Name != "retry" {
+			t.Errorf("Expected a retry event, got %v", ended[0].Events())
+		}
+	})
+
+	t.Run("should mark failed calls as errors", func(t *testing.T) {
+		if ended[0].Status().Code == codes.Error {
+			t.Error("Expected successful call not to be an error")
+		}
+		if ended[1].Status().Code != codes.Error {
+			t.Errorf("Expected failed call to be an error, got %v", ended[1].Status())
+		}
+	})
+
+	t.Run("should propagate trace context", func(t *testing.T) {
+		header, _ := traceparent.Load().(string)
+		want := ended[0].SpanContext().TraceID().String()
+		if len(header) < 36 || header[3:35] != want {
+			t.Errorf("Expected traceparent with trace ID %s, got %q", want, header)
+		}
+	})
+
+	t.Run("should record metrics", func(t *testing.T) {
+		var rm metricdata.ResourceMetrics
+		if err := reader.Collect(context.Background(), &rm); err != nil {
+			t.Fatal(err)
+		}
+		sums := map[string]int64{}
+		calls := uint64(0)
+		for _, sm := range rm.ScopeMetrics {
+			for _, m := range sm.Metrics {
+				switch data := m.Data.(type) {
+				case metricdata.Sum[int64]:
+					for _, dp := range data.DataPoints {
+						sums[m.Name] += dp.Value
+					}
+				case metricdata.Histogram[float64]:
+					for _, dp := range data.DataPoints {
+						calls += dp.Count
+					}
+				}
+			}
+		}
+		if calls != 2 {
+			t.Errorf("Expected 2 call durations, got %d", calls)
+		}
+		for name, want := range map[string]int64{
+			"oway.client.call.errors":     1,
+			"oway.client.call.retries":    1,
+			"oway.client.token.refreshes": 1,
+		} {
+			if sums[name] != want {
+				t.Errorf("Expected %s=%d, got %d", name, want, sums[name])
+			}
+		}
+	})
+}
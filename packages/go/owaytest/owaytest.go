@@ -0,0 +1,669 @@
+// Package owaytest provides an in-process fake of the Oway API for testing
+// integrations offline. The fake is stateful: quotes and shipments created
+// through it can be confirmed, tracked, advanced through their lifecycle and
+// invoiced, and carrier uploads are kept for inspection.
+package owaytest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+
+	oway "github.com/Oway-Inc/oway-sdk/packages/go"
+	"github.com/Oway-Inc/oway-sdk/packages/go/client"
+)
+
+// Credentials accepted by the fake server
+const (
+	ClientID      = "owaytest_client"
+	ClientSecret  = "owaytest_secret"
+	ShipperAPIKey = "oway_sk_test_owaytest"
+	CarrierAPIKey = "oway_ck_test_owaytest"
+)
+
+// Request is a request recorded by the fake server
+type Request struct {
+	Method    string
+	Path      string
+	Operation string
+	Header    http.Header
+	Body      []byte
+	Time      time.Time
+}
+
+// Fault is an injected failure or delay for an operation
+type Fault struct {
+	// Status is the HTTP status to respond with (0 only applies Latency)
+	Status int
+
+	// Reason is the ProblemDetail reason code sent with Status
+	Reason string
+
+	// Header is added to the fault response (e.g. Retry-After)
+	Header http.Header
+
+	// Latency delays the response
+	Latency time.Duration
+
+	// Times limits how many requests the fault applies to (0 means until ClearFaults)
+	Times int
+}
+
+// Server is a fake Oway API server
+type Server struct {
+	server *httptest.Server
+
+	mu          sync.Mutex
+	tokens      map[string]bool
+	quotes      map[string]*quoteRecord
+	shipments   map[string]*shipmentRecord
+	idempotency map[string]string
+	gpsData     map[string][]oway.GpsData
+	trips       map[string][]oway.TripRequest
+	jobs        map[string][]oway.Job
+	faults      map[string][]*Fault
+	requests    []Request
+	sequence    int
+	orders      int
+}
+
+type quoteRecord struct {
+	apiKey  string
+	request oway.QuoteRequest
+	quote   oway.Quote
+}
+
+type shipmentRecord struct {
+	apiKey   string
+	request  oway.ShipmentRequest
+	shipment oway.Shipment
+	tracking oway.Tracking
+}
+
+// NewServer starts a fake Oway API server. Call Close when done.
+func NewServer() *Server {
+	s := &Server{
+		tokens:      make(map[string]bool),
+		quotes:      make(map[string]*quoteRecord),
+		shipments:   make(map[string]*shipmentRecord),
+		idempotency: make(map[string]string),
+		gpsData:     make(map[string][]oway.GpsData),
+		trips:       make(map[string][]oway.TripRequest),
+		jobs:        make(map[string][]oway.Job),
+		faults:      make(map[string][]*Fault),
+	}
+
+	mux := http.NewServeMux()
+	s.handle(mux, "POST /v1/auth/token", oway.OperationGetToken, s.getToken)
+	s.handle(mux, "POST /v1/shipper/quote", oway.OperationRequestQuote, s.requestQuote)
+	s.handle(mux, "GET /v1/shipper/quote/{quoteId}", oway.OperationGetQuote, s.getQuote)
+	s.handle(mux, "POST /v1/shipper/shipment", oway.OperationCreateShipment, s.createShipment)
+	s.handle(mux, "GET /v1/shipper/shipment/{orderNumber}", oway.OperationGetShipment, s.getShipment)
+	s.handle(mux, "PUT /v1/shipper/shipment/{orderNumber}/confirm", oway.OperationConfirmShipment, s.confirmShipment)
+	s.handle(mux, "PUT /v1/shipper/shipment/{orderNumber}/cancel", oway.OperationCancelShipment, s.cancelShipment)
+	s.handle(mux, "GET /v1/shipper/shipment/{orderNumber}/tracking", oway.OperationTrackShipment, s.trackShipment)
+	s.handle(mux, "GET /v1/shipper/shipment/{orderNumber}/invoice", oway.OperationGetInvoice, s.getInvoice)
+	s.handle(mux, "GET /v1/shipper/shipment/{orderNumber}/document/{documentType}", oway.OperationGetDocument, s.getDocument)
+	s.handle(mux, "GET /v1/carrier/{carrierId}", oway.OperationGetCarrierApiConfig, s.getCarrierApiConfig)
+	s.handle(mux, "POST /v1/carrier/{carrierId}/gps-data", oway.OperationAddGpsData, s.addGpsData)
+	s.handle(mux, "GET /v1/carrier/{carrierId}/jobs", oway.OperationGetJobs, s.getJobs)
+	s.handle(mux, "POST /v1/carrier/{carrierId}/trips", oway.OperationAddTrips, s.addTrips)
+
+	s.server = httptest.NewServer(mux)
+	return s
+}
+
+// URL returns the base URL of the fake server
+func (s *Server) URL() string {
+	return s.server.URL
+}
+
+// Close shuts down the fake server
+func (s *Server) Close() {
+	s.server.Close()
+}
+
+// Config returns an oway.Config pointing at the fake server with valid
+// credentials, the shipper and carrier API keys, and fast retries
+func (s *Server) Config() oway.Config {
+	return oway.Config{
+		ClientID:      ClientID,
+		ClientSecret:  ClientSecret,
+		APIKey:        ShipperAPIKey,
+		CarrierAPIKey: CarrierAPIKey,
+		BaseURL:       s.server.URL,
+		TokenURL:      s.server.URL + "/v1/auth/token",
+		Retry:         &oway.RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: 10 * time.Millisecond},
+	}
+}
+
+// Requests returns the requests received so far, oldest first
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Request(nil), s.requests...)
+}
+
+// RequestsFor returns the recorded requests for one operation (e.g. oway.OperationCreateShipment)
+func (s *Server) RequestsFor(operation string) []Request {
+	var matched []Request
+	for _, r := range s.Requests() {
+		if r.Operation == operation {
+			matched = append(matched, r)
+		}
+	}
+	return matched
+}
+
+// InjectFault makes requests for an operation fail or slow down
+func (s *Server) InjectFault(operation string, fault Fault) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults[operation] = append(s.faults[operation], &fault)
+}
+
+// ClearFaults removes all injected faults
+func (s *Server) ClearFaults() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults = make(map[string][]*Fault)
+}
+
+// RevokeTokens invalidates every issued access token
+func (s *Server) RevokeTokens() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tokens = make(map[string]bool)
+}
+
+// SetStatus moves a shipment to any status, bypassing transition rules
+func (s *Server) SetStatus(orderNumber string, status oway.ShipmentOrderStatus) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	record, ok := s.shipments[orderNumber]
+	if !ok {
+		return fmt.Errorf("owaytest: unknown order %s", orderNumber)
+	}
+	record.setStatus(status)
+	return nil
+}
+
+// Advance moves a shipment to its next status along the delivery path and
+// returns the new status. Terminal shipments cannot be advanced.
+func (s *Server) Advance(orderNumber string) (oway.ShipmentOrderStatus, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	record, ok := s.shipments[orderNumber]
+	if !ok {
+		return "", fmt.Errorf("owaytest: unknown order %s", orderNumber)
+	}
+
+	current := record.status()
+	next, ok := deliveryPath[current]
+	if !ok {
+		return "", fmt.Errorf("owaytest: order %s is %s and cannot advance", orderNumber, current)
+	}
+	record.setStatus(next)
+	return next, nil
+}
+
+// SetJobs sets the jobs returned by GetJobs for a carrier
+func (s *Server) SetJobs(carrierID string, jobs []oway.Job) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.jobs[carrierID] = jobs
+}
+
+// GpsData returns the GPS points received for a carrier
+func (s *Server) GpsData(carrierID string) []oway.GpsData {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]oway.GpsData(nil), s.gpsData[carrierID]...)
+}
+
+// Trips returns the trips received for a carrier
+func (s *Server) Trips(carrierID string) []oway.TripRequest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]oway.TripRequest(nil), s.trips[carrierID]...)
+}
+
+type handlerFunc func(w http.ResponseWriter, r *http.Request, apiKey string, body []byte)
+
+// handle registers an operation with request recording, fault injection and
+// authentication applied in front of it
+func (s *Server) handle(mux *http.ServeMux, pattern, operation string, h handlerFunc) {
+	mux.HandleFunc(pattern, func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		s.mu.Lock()
+		s.requests = append(s.requests, Request{
+			Method:    r.Method,
+			Path:      r.URL.Path,
+			Operation: operation,
+			Header:    r.Header.Clone(),
+			Body:      body,
+			Time:      time.Now(),
+		})
+		fault := s.takeFault(operation)
+		s.mu.Unlock()
+
+		if fault != nil {
+			if fault.Latency > 0 {
+				select {
+				case <-time.After(fault.Latency):
+				case <-r.Context().Done():
+					return
+				}
+			}
+			if fault.Status != 0 {
+				for k, v := range fault.Header {
+					w.Header()[k] = v
+				}
+				writeProblem(w, fault.Status, http.StatusText(fault.Status), "injected fault", fault.Reason)
+				return
+			}
+		}
+
+		if operation == oway.OperationGetToken {
+			h(w, r, "", body)
+			return
+		}
+
+		token, _ := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		s.mu.Lock()
+		valid := s.tokens[token]
+		s.mu.Unlock()
+		if !valid {
+			writeProblem(w, http.StatusUnauthorized, "Unauthorized", "missing or invalid bearer token", "INVALID_TOKEN")
+			return
+		}
+
+		apiKey := r.Header.Get("x-oway-api-key")
+		prefix := "oway_sk_"
+		if strings.HasPrefix(r.URL.Path, "/v1/carrier/") {
+			prefix = "oway_ck_"
+		}
+		if !strings.HasPrefix(apiKey, prefix) {
+			writeProblem(w, http.StatusForbidden, "Forbidden", fmt.Sprintf("x-oway-api-key must be a %s... key", prefix), "INVALID_API_KEY")
+			return
+		}
+
+		h(w, r, apiKey, body)
+	})
+}
+
+// takeFault returns the next fault for an operation; s.mu must be held
+func (s *Server) takeFault(operation string) *Fault {
+	faults := s.faults[operation]
+	if len(faults) == 0 {
+		return nil
+	}
+	fault := faults[0]
+	if fault.Times > 0 {
+		fault.Times--
+		if fault.Times == 0 {
+			s.faults[operation] = faults[1:]
+		}
+	}
+	return fault
+}
+
+func (s *Server) getToken(w http.ResponseWriter, r *http.Request, _ string, body []byte) {
+	var req client.TokenRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		writeJSON(w, http.StatusBadRequest, client.TokenErrorResponse{Error: ptr("invalid_request"), ErrorDescription: ptr(err.Error())})
+		return
+	}
+	if req.ClientId != ClientID || req.ClientSecret != ClientSecret {
+		writeJSON(w, http.StatusUnauthorized, client.TokenErrorResponse{Error: ptr("invalid_client"), ErrorDescription: ptr("invalid client credentials")})
+		return
+	}
+
+	s.mu.Lock()
+	s.sequence++
+	token := fmt.Sprintf("owaytest_token_%d", s.sequence)
+	s.tokens[token] = true
+	s.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, client.TokenResponse{AccessToken: &token, ExpiresIn: ptr(int32(3600)), TokenType: ptr("Bearer")})
+}
+
+func (s *Server) requestQuote(w http.ResponseWriter, r *http.Request, apiKey string, body []byte) {
+	var req oway.QuoteRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		writeProblem(w, http.StatusBadRequest, "Bad Request", err.Error(), "INVALID_REQUEST")
+		return
+	}
+	if len(req.OrderComponents) == 0 {
+		writeProblem(w, http.StatusBadRequest, "Bad Request", "orderComponents must not be empty", "INVALID_REQUEST")
+		return
+	}
+
+	s.mu.Lock()
+	s.sequence++
+	id := fmt.Sprintf("quote_%d", s.sequence)
+	record := &quoteRecord{
+		apiKey:  apiKey,
+		request: req,
+		quote: oway.Quote{
+			Id:                  &id,
+			QuotedPriceInCents:  ptr(price(req.OrderComponents)),
+			QuoteExpirationTime: ptr(time.Now().Add(48 * time.Hour).UTC()),
+		},
+	}
+	s.quotes[id] = record
+	s.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, record.quote)
+}
+
+func (s *Server) getQuote(w http.ResponseWriter, r *http.Request, apiKey string, _ []byte) {
+	s.mu.Lock()
+	record, ok := s.quotes[r.PathValue("quoteId")]
+	s.mu.Unlock()
+	if !ok || record.apiKey != apiKey {
+		writeProblem(w, http.StatusNotFound, "Not Found", "quote not found", "QUOTE_NOT_FOUND")
+		return
+	}
+	writeJSON(w, http.StatusOK, record.quote)
+}
+
+func (s *Server) createShipment(w http.ResponseWriter, r *http.Request, apiKey string, body []byte) {
+	var req oway.ShipmentRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		writeProblem(w, http.StatusBadRequest, "Bad Request", err.Error(), "INVALID_REQUEST")
+		return
+	}
+	if len(req.OrderComponents) == 0 {
+		writeProblem(w, http.StatusBadRequest, "Bad Request", "orderComponents must not be empty", "INVALID_REQUEST")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := idempotencyKey(r, apiKey, oway.OperationCreateShipment)
+	if orderNumber, ok := s.idempotency[key]; ok {
+		writeJSON(w, http.StatusOK, s.shipments[orderNumber].shipment)
+		return
+	}
+
+	priceInCents := price(req.OrderComponents)
+	if req.QuoteId != nil {
+		quote, ok := s.quotes[*req.QuoteId]
+		if !ok || quote.apiKey != apiKey {
+			writeProblem(w, http.StatusUnprocessableEntity, "Unprocessable Entity", "quote not found", "QUOTE_NOT_FOUND")
+			return
+		}
+		priceInCents = *quote.quote.QuotedPriceInCents
+	}
+
+	s.orders++
+	now := time.Now().UTC()
+	orderNumber := fmt.Sprintf("T%04d", s.orders)
+	id := fmt.Sprintf("order_%d", s.orders)
+	record := &shipmentRecord{
+		apiKey:  apiKey,
+		request: req,
+		shipment: oway.Shipment{
+			Id:                &id,
+			OrderNumber:       &orderNumber,
+			TotalPriceInCents: &priceInCents,
+			CreatedAt:         &now,
+		},
+		tracking: oway.Tracking{
+			Id:                    &id,
+			OrderNumber:           &orderNumber,
+			EstimatedPickupDate:   ptr(now.Add(24 * time.Hour)),
+			EstimatedDeliveryDate: ptr(now.Add(72 * time.Hour)),
+		},
+	}
+	record.setStatus(initialized)
+	s.shipments[orderNumber] = record
+	if key != "" {
+		s.idempotency[key] = orderNumber
+	}
+
+	writeJSON(w, http.StatusOK, record.shipment)
+}
+
+func (s *Server) getShipment(w http.ResponseWriter, r *http.Request, apiKey string, _ []byte) {
+	s.withShipment(w, r, apiKey, func(record *shipmentRecord) {
+		writeJSON(w, http.StatusOK, record.shipment)
+	})
+}
+
+func (s *Server) confirmShipment(w http.ResponseWriter, r *http.Request, apiKey string, _ []byte) {
+	s.transition(w, r, apiKey, oway.OperationConfirmShipment, confirmed)
+}
+
+func (s *Server) cancelShipment(w http.ResponseWriter, r *http.Request, apiKey string, _ []byte) {
+	s.transition(w, r, apiKey, oway.OperationCancelShipment, cancelled)
+}
+
+func (s *Server) trackShipment(w http.ResponseWriter, r *http.Request, apiKey string, _ []byte) {
+	s.withShipment(w, r, apiKey, func(record *shipmentRecord) {
+		writeJSON(w, http.StatusOK, record.tracking)
+	})
+}
+
+func (s *Server) getInvoice(w http.ResponseWriter, r *http.Request, apiKey string, _ []byte) {
+	s.withShipment(w, r, apiKey, func(record *shipmentRecord) {
+		if err := checkStatus(oway.OperationGetInvoice, record.status(), allowedStatuses[oway.OperationGetInvoice]); err != nil {
+			writeProblem(w, http.StatusBadRequest, "Bad Request", err.Error(), "INVALID_ORDER_STATUS")
+			return
+		}
+
+		var pieces, weight int32
+		for _, c := range record.request.OrderComponents {
+			pieces += c.PalletCount
+			weight += c.PalletCount * c.PoundsWeight
+		}
+		writeJSON(w, http.StatusOK, oway.Invoice{
+			OrderId:             record.shipment.Id,
+			OrderNumber:         record.shipment.OrderNumber,
+			PoNumber:            record.request.PoNumber,
+			RefNumber:           record.request.RefNumber,
+			Shipper:             &record.request.PickupAddress,
+			Consignee:           &record.request.DeliveryAddress,
+			ShipDate:            record.tracking.ActualPickupDate,
+			DeliveryDate:        record.tracking.ActualDeliveryDate,
+			InvoiceDate:         record.tracking.ActualDeliveryDate,
+			TotalChargesInCents: record.shipment.TotalPriceInCents,
+			TotalPieces:         &pieces,
+			TotalWeight:         &weight,
+			Charges: &[]client.InvoiceCharge{
+				{ChargeType: ptr("LINEHAUL"), Description: ptr("Linehaul"), AmountInCents: record.shipment.TotalPriceInCents},
+			},
+		})
+	})
+}
+
+func (s *Server) getDocument(w http.ResponseWriter, r *http.Request, apiKey string, _ []byte) {
+	s.withShipment(w, r, apiKey, func(record *shipmentRecord) {
+		documentType := r.PathValue("documentType")
+		switch oway.DocumentType(documentType) {
+		case oway.DocumentTypeBOL, oway.DocumentTypeInvoice, oway.DocumentTypeShippingLabel, oway.DocumentTypePOD:
+		default:
+			writeProblem(w, http.StatusBadRequest, "Bad Request", "unknown document type "+documentType, "INVALID_DOCUMENT_TYPE")
+			return
+		}
+		if err := checkStatus(oway.OperationGetDocument+" "+documentType, record.status(), documentStatuses(oway.DocumentType(documentType))); err != nil {
+			writeProblem(w, http.StatusNotFound, "Not Found", err.Error(), "DOCUMENT_NOT_AVAILABLE")
+			return
+		}
+
+		filename := fmt.Sprintf("%s_%s.pdf", *record.shipment.OrderNumber, strings.ToLower(documentType))
+		writeJSON(w, http.StatusOK, oway.Document{
+			DownloadLink: ptr(s.server.URL + "/documents/" + filename),
+			FileType:     ptr("application/pdf"),
+			Filename:     &filename,
+		})
+	})
+}
+
+func (s *Server) getCarrierApiConfig(w http.ResponseWriter, r *http.Request, _ string, _ []byte) {
+	writeJSON(w, http.StatusOK, oway.CarrierConfig{
+		ApiEnabled:  ptr(true),
+		ApiVersion:  ptr("v1"),
+		CompanyName: ptr("Carrier " + r.PathValue("carrierId")),
+		AvailableEndpoints: &[]string{
+			"/v1/carrier/{carrierId}/gps-data",
+			"/v1/carrier/{carrierId}/jobs",
+			"/v1/carrier/{carrierId}/trips",
+		},
+	})
+}
+
+func (s *Server) addGpsData(w http.ResponseWriter, r *http.Request, _ string, body []byte) {
+	var points []oway.GpsData
+	if err := json.Unmarshal(body, &points); err != nil {
+		writeProblem(w, http.StatusBadRequest, "Bad Request", err.Error(), "INVALID_REQUEST")
+		return
+	}
+
+	s.mu.Lock()
+	carrierID := r.PathValue("carrierId")
+	s.gpsData[carrierID] = append(s.gpsData[carrierID], points...)
+	s.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, len(points))
+}
+
+func (s *Server) getJobs(w http.ResponseWriter, r *http.Request, _ string, _ []byte) {
+	s.mu.Lock()
+	jobs := s.jobs[r.PathValue("carrierId")]
+	s.mu.Unlock()
+	if jobs == nil {
+		jobs = []oway.Job{}
+	}
+	writeJSON(w, http.StatusOK, jobs)
+}
+
+func (s *Server) addTrips(w http.ResponseWriter, r *http.Request, _ string, body []byte) {
+	var trips []oway.TripRequest
+	if err := json.Unmarshal(body, &trips); err != nil {
+		writeProblem(w, http.StatusBadRequest, "Bad Request", err.Error(), "INVALID_REQUEST")
+		return
+	}
+
+	s.mu.Lock()
+	carrierID := r.PathValue("carrierId")
+	s.trips[carrierID] = append(s.trips[carrierID], trips...)
+	s.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, len(trips))
+}
+
+// withShipment looks up the shipment in the path for the calling company
+func (s *Server) withShipment(w http.ResponseWriter, r *http.Request, apiKey string, fn func(*shipmentRecord)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	record, ok := s.shipments[r.PathValue("orderNumber")]
+	if !ok || record.apiKey != apiKey {
+		writeProblem(w, http.StatusNotFound, "Not Found", "shipment not found", "ORDER_NOT_FOUND")
+		return
+	}
+	fn(record)
+}
+
+// transition applies a shipper-initiated status change if the shipment's
+// status allows operation. A repeated Idempotency-Key returns the shipment
+// without applying the change again.
+func (s *Server) transition(w http.ResponseWriter, r *http.Request, apiKey, operation string, to oway.ShipmentOrderStatus) {
+	s.withShipment(w, r, apiKey, func(record *shipmentRecord) {
+		orderNumber := *record.shipment.OrderNumber
+		key := idempotencyKey(r, apiKey, operation)
+		if previous, ok := s.idempotency[key]; ok {
+			if previous != orderNumber {
+				writeProblem(w, http.StatusUnprocessableEntity, "Unprocessable Entity", "Idempotency-Key was used for order "+previous, "IDEMPOTENCY_KEY_REUSED")
+				return
+			}
+			writeJSON(w, http.StatusOK, record.shipment)
+			return
+		}
+
+		if err := checkStatus(operation, record.status(), allowedStatuses[operation]); err != nil {
+			writeProblem(w, http.StatusBadRequest, "Bad Request", err.Error(), "INVALID_ORDER_STATUS")
+			return
+		}
+		record.setStatus(to)
+		if key != "" {
+			s.idempotency[key] = orderNumber
+		}
+		writeJSON(w, http.StatusOK, record.shipment)
+	})
+}
+
+// idempotencyKey scopes the request's Idempotency-Key to the calling company
+// and operation, or returns "" if the request has none
+func idempotencyKey(r *http.Request, apiKey, operation string) string {
+	key := r.Header.Get("Idempotency-Key")
+	if key == "" {
+		return ""
+	}
+	return apiKey + "|" + operation + "|" + key
+}
+
+func (r *shipmentRecord) status() oway.ShipmentOrderStatus {
+	return *r.shipment.OrderStatus
+}
+
+func (r *shipmentRecord) setStatus(status oway.ShipmentOrderStatus) {
+	now := time.Now().UTC()
+	r.shipment.OrderStatus = ptr(status)
+	r.shipment.UpdatedAt = &now
+	r.tracking.OrderStatus = ptr(oway.TrackingOrderStatus(status))
+
+	switch status {
+	case pickedUp:
+		r.tracking.ActualPickupDate = &now
+	case delivered:
+		if r.tracking.ActualPickupDate == nil {
+			r.tracking.ActualPickupDate = &now
+		}
+		r.tracking.ActualDeliveryDate = &now
+	}
+}
+
+// price is the fake's deterministic quote: $150 plus $75 per pallet
+func price(components []oway.OrderComponent) int32 {
+	var pallets int32
+	for _, c := range components {
+		pallets += c.PalletCount
+	}
+	return 15000 + 7500*pallets
+}
+
+func writeProblem(w http.ResponseWriter, status int, title, detail, reason string) {
+	problem := client.ProblemDetail{
+		Title:  &title,
+		Detail: &detail,
+		Status: ptr(int32(status)),
+	}
+	if reason != "" {
+		problem.Reason = &reason
+	}
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(problem)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	var buf bytes.Buffer
+	json.NewEncoder(&buf).Encode(v)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(buf.Bytes())
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
@@ -0,0 +1,233 @@
+package owaytest
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	oway "github.com/Oway-Inc/oway-sdk/packages/go"
+)
+
+func TestBookingFlow(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+
+	client, err := oway.New(server.Config())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	address := oway.Address{Name: "Warehouse", Address1: "1 Main St", City: "Austin", State: "TX", ZipCode: "78701", PhoneNumber: "+15125550100", ContactPerson: "Pat"}
+	components := []oway.OrderComponent{{PalletCount: 2, PoundsWeight: 500, PalletDimensions: []int32{48, 40, 48}}}
+
+	quote, err := client.RequestQuote(ctx, &oway.QuoteRequest{PickupAddress: address, DeliveryAddress: address, OrderComponents: components})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	shipment, err := client.CreateShipment(ctx, &oway.ShipmentRequest{QuoteId: quote.Id, PickupAddress: address, DeliveryAddress: address, OrderComponents: components, Description: "Widgets"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *shipment.TotalPriceInCents != *quote.QuotedPriceInCents {
+		t.Errorf("Expected shipment price to match quote")
+	}
+	orderNumber := *shipment.OrderNumber
+
+	if _, err := client.ConfirmShipment(ctx, orderNumber); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = client.GetInvoice(ctx, orderNumber)
+	var apiErr *oway.Error
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadRequest {
+		t.Fatalf("Expected 400 for invoice before delivery, got %v", err)
+	}
+
+	for {
+		status, err := server.Advance(orderNumber)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if status == oway.ShipmentOrderStatus(oway.TrackingStatusDelivered) {
+			break
+		}
+	}
+
+	tracking, err := client.TrackShipment(ctx, orderNumber)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *tracking.OrderStatus != oway.TrackingStatusDelivered || tracking.ActualDeliveryDate == nil {
+		t.Errorf("Expected delivered tracking, got %+v", tracking)
+	}
+
+	invoice, err := client.GetInvoice(ctx, orderNumber)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *invoice.TotalPieces != 2 || *invoice.TotalWeight != 1000 {
+		t.Errorf("Unexpected invoice totals %d pieces, %d lb", *invoice.TotalPieces, *invoice.TotalWeight)
+	}
+
+	if _, err := client.GetDocument(ctx, orderNumber, oway.DocumentTypePOD); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := len(server.RequestsFor(oway.OperationCreateShipment)); got != 1 {
+		t.Errorf("Expected 1 create request, got %d", got)
+	}
+}
+
+func TestAuthentication(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+	ctx := context.Background()
+
+	t.Run("should reject bad credentials", func(t *testing.T) {
+		config := server.Config()
+		config.ClientSecret = "wrong"
+		client, _ := oway.New(config)
+		if _, err := client.GetShipment(ctx, "T0001"); err == nil {
+			t.Error("Expected error for invalid credentials")
+		}
+	})
+
+	t.Run("should require a carrier key on carrier endpoints", func(t *testing.T) {
+		config := server.Config()
+		config.CarrierAPIKey = ""
+		config.APIKey = ""
+		client, _ := oway.New(config)
+		_, err := client.GetCarrierApiConfig(ctx, "carrier_1")
+		var apiErr *oway.Error
+		if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusForbidden {
+			t.Fatalf("Expected 403, got %v", err)
+		}
+
+		if _, err := client.GetCarrierApiConfigForCompany(ctx, "carrier_1", ShipperAPIKey); !errors.Is(err, oway.ErrAPIKeyType) {
+			t.Fatalf("Expected ErrAPIKeyType, got %v", err)
+		}
+
+		if _, err := client.GetCarrierApiConfigForCompany(ctx, "carrier_1", CarrierAPIKey); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
+
+func TestFaults(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+
+	client, err := oway.New(server.Config())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	server.InjectFault(oway.OperationAddGpsData, Fault{Status: http.StatusServiceUnavailable, Times: 2})
+	added, err := client.AddGpsDataForCompany(ctx, "carrier_1", []oway.GpsData{{VehicleId: "truck_1", Timestamp: time.Now()}}, CarrierAPIKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if added != 1 || len(server.GpsData("carrier_1")) != 1 {
+		t.Errorf("Expected 1 point stored, got %d", len(server.GpsData("carrier_1")))
+	}
+	if got := len(server.RequestsFor(oway.OperationAddGpsData)); got != 3 {
+		t.Errorf("Expected 3 attempts, got %d", got)
+	}
+
+	server.InjectFault(oway.OperationTrackShipment, Fault{Latency: time.Second})
+	ctx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	if _, err := client.TrackShipment(ctx, "T0001"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected deadline exceeded, got %v", err)
+	}
+}
+
+func TestShipperIdempotency(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+
+	client, err := oway.New(server.Config())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	address := oway.Address{Name: "Warehouse", Address1: "1 Main St", City: "Austin", State: "TX", ZipCode: "78701", PhoneNumber: "+15125550100", ContactPerson: "Pat"}
+	components := []oway.OrderComponent{{PalletCount: 1, PoundsWeight: 500, PalletDimensions: []int32{48, 40, 48}}}
+	create := func() string {
+		t.Helper()
+		if _, err := client.RequestQuote(ctx, &oway.QuoteRequest{PickupAddress: address, DeliveryAddress: address, OrderComponents: components}); err != nil {
+			t.Fatal(err)
+		}
+		shipment, err := client.CreateShipment(ctx, &oway.ShipmentRequest{PickupAddress: address, DeliveryAddress: address, OrderComponents: components, Description: "Widgets"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return *shipment.OrderNumber
+	}
+	first, second := create(), create()
+
+	// Each call uses a new client so the SDK's own dedup cache does not
+	// answer repeated keys before they reach the server
+	newClient := func() *oway.Client {
+		t.Helper()
+		client, err := oway.New(server.Config())
+		if err != nil {
+			t.Fatal(err)
+		}
+		return client
+	}
+
+	t.Run("should number orders independently of tokens and quotes", func(t *testing.T) {
+		if first != "T0001" || second != "T0002" {
+			t.Errorf("Expected T0001 and T0002, got %s and %s", first, second)
+		}
+	})
+
+	t.Run("should replay a confirm with the same Idempotency-Key", func(t *testing.T) {
+		keyed := oway.WithIdempotencyKey(ctx, "confirm-1")
+		for i := 0; i < 2; i++ {
+			shipment, err := newClient().ConfirmShipment(keyed, first)
+			if err != nil {
+				t.Fatalf("Confirm %d: %v", i+1, err)
+			}
+			if *shipment.OrderStatus != oway.ShipmentOrderStatus(oway.TrackingStatusConfirmed) {
+				t.Errorf("Expected CONFIRMED, got %s", *shipment.OrderStatus)
+			}
+		}
+
+		if got := len(server.RequestsFor(oway.OperationConfirmShipment)); got != 2 {
+			t.Errorf("Expected 2 confirm requests, got %d", got)
+		}
+		_, err := client.ConfirmShipment(ctx, first)
+		var apiErr *oway.Error
+		if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadRequest {
+			t.Errorf("Expected 400 for a second confirm without the key, got %v", err)
+		}
+	})
+
+	t.Run("should replay a cancel with the same Idempotency-Key", func(t *testing.T) {
+		keyed := oway.WithIdempotencyKey(ctx, "cancel-1")
+		for i := 0; i < 2; i++ {
+			if _, err := newClient().CancelShipment(keyed, first); err != nil {
+				t.Fatalf("Cancel %d: %v", i+1, err)
+			}
+		}
+		if got := len(server.RequestsFor(oway.OperationCancelShipment)); got != 2 {
+			t.Errorf("Expected 2 cancel requests, got %d", got)
+		}
+	})
+
+	t.Run("should reject an Idempotency-Key reused for another order", func(t *testing.T) {
+		_, err := newClient().CancelShipment(oway.WithIdempotencyKey(ctx, "cancel-1"), second)
+		var apiErr *oway.Error
+		if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusUnprocessableEntity || apiErr.Reason != "IDEMPOTENCY_KEY_REUSED" {
+			t.Errorf("Expected 422 IDEMPOTENCY_KEY_REUSED, got %v", err)
+		}
+	})
+}
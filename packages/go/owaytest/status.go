@@ -0,0 +1,60 @@
+package owaytest
+
+import (
+	"fmt"
+	"slices"
+
+	oway "github.com/Oway-Inc/oway-sdk/packages/go"
+	"github.com/Oway-Inc/oway-sdk/packages/go/client"
+)
+
+// The fake's status rules are its own description of the API rather than the
+// SDK's lifecycle package, so code built on lifecycle is tested against an
+// independent source instead of against itself.
+
+// Shipment statuses, as named by the API
+const (
+	initialized = client.ShipmentOrderStatusINITIALIZED
+	confirmed   = client.ShipmentOrderStatusCONFIRMED
+	accepted    = client.ShipmentOrderStatusACCEPTED
+	assigned    = client.ShipmentOrderStatusASSIGNED
+	pickedUp    = client.ShipmentOrderStatusPICKEDUP
+	inTransit   = client.ShipmentOrderStatusINTRANSIT
+	delivered   = client.ShipmentOrderStatusDELIVERED
+	cancelled   = client.ShipmentOrderStatusCANCELLED
+)
+
+// deliveryPath is the next status along the way to delivery, used by Advance
+var deliveryPath = map[oway.ShipmentOrderStatus]oway.ShipmentOrderStatus{
+	initialized: confirmed,
+	confirmed:   accepted,
+	accepted:    assigned,
+	assigned:    pickedUp,
+	pickedUp:    inTransit,
+	inTransit:   delivered,
+}
+
+// allowedStatuses lists the statuses in which each shipper operation is accepted
+var allowedStatuses = map[string][]oway.ShipmentOrderStatus{
+	oway.OperationConfirmShipment: {initialized},
+	oway.OperationCancelShipment:  {initialized, confirmed, accepted, assigned},
+	oway.OperationGetInvoice:      {delivered},
+}
+
+// documentStatuses lists the statuses in which a document type is available
+func documentStatuses(documentType oway.DocumentType) []oway.ShipmentOrderStatus {
+	switch documentType {
+	case oway.DocumentTypePOD, oway.DocumentTypeInvoice:
+		return []oway.ShipmentOrderStatus{delivered}
+	default:
+		return []oway.ShipmentOrderStatus{confirmed, accepted, assigned, pickedUp, inTransit, delivered}
+	}
+}
+
+// checkStatus returns an error if operation is not accepted in status
+func checkStatus(operation string, status oway.ShipmentOrderStatus, allowed []oway.ShipmentOrderStatus) error {
+	if slices.Contains(allowed, status) {
+		return nil
+	}
+	return fmt.Errorf("%s is not allowed for a %s shipment", operation, status)
+}
@@ -0,0 +1,327 @@
+package oway
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// RateLimit configures client-side token-bucket rate limiting. Calls wait,
+// honoring context cancellation, until both the shared bucket and their
+// company's bucket have capacity. Each bucket slows down when the API answers
+// 429 or reports an exhausted quota in rate-limit headers, and recovers
+// gradually as calls succeed. Every attempt, including retries, counts.
+type RateLimit struct {
+	// RequestsPerSecond limits all calls of the client together (0: unlimited)
+	RequestsPerSecond float64
+
+	// Burst is how many calls may be sent at once before RequestsPerSecond
+	// applies (default: RequestsPerSecond rounded up)
+	Burst int
+
+	// PerCompanyRequestsPerSecond limits calls per x-oway-api-key (0: unlimited)
+	PerCompanyRequestsPerSecond float64
+
+	// PerCompanyBurst is the burst per x-oway-api-key
+	// (default: PerCompanyRequestsPerSecond rounded up)
+	PerCompanyBurst int
+}
+
+// RateLimitStats reports the state of the client's rate limiter
+type RateLimitStats struct {
+	// Global is the bucket shared by all calls (nil if unlimited)
+	Global *BucketStats
+
+	// Companies holds per-company buckets, keyed by redacted API key
+	Companies map[string]BucketStats
+}
+
+// BucketStats reports the state of one rate-limit bucket
+type BucketStats struct {
+	// Limit is the configured rate in requests per second
+	Limit float64
+
+	// Rate is the current rate, lower than Limit after throttling by the API
+	Rate float64
+
+	// Utilization is the fraction of the burst capacity in use (0.0 - 1.0)
+	Utilization float64
+
+	// Waiting is the number of calls blocked on the bucket
+	Waiting int
+
+	// PausedUntil is when a 429 or exhausted quota stops pausing the bucket
+	PausedUntil time.Time
+}
+
+// Adaptive rate bounds: throttling halves the rate down to a floor, and each
+// success restores a step of the configured limit
+const (
+	rateLimitFloor    = 0.1
+	rateLimitRecovery = 0.05
+)
+
+// rateLimiter holds the shared bucket and one bucket per company API key
+type rateLimiter struct {
+	config RateLimit
+	global *tokenBucket
+
+	mu        sync.Mutex
+	companies map[string]*tokenBucket
+}
+
+func newRateLimiter(config RateLimit) *rateLimiter {
+	l := &rateLimiter{config: config, companies: make(map[string]*tokenBucket)}
+	if config.RequestsPerSecond > 0 {
+		l.global = newTokenBucket(config.RequestsPerSecond, config.Burst)
+	}
+	return l
+}
+
+// company returns the bucket for an API key, or nil if per-company limiting is off
+func (l *rateLimiter) company(apiKey string) *tokenBucket {
+	if l.config.PerCompanyRequestsPerSecond <= 0 {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	b, ok := l.companies[apiKey]
+	if !ok {
+		b = newTokenBucket(l.config.PerCompanyRequestsPerSecond, l.config.PerCompanyBurst)
+		l.companies[apiKey] = b
+	}
+	return b
+}
+
+// wait blocks until a call for apiKey may be sent
+func (l *rateLimiter) wait(ctx context.Context, apiKey string) error {
+	company := l.company(apiKey)
+	if company != nil {
+		if err := company.wait(ctx); err != nil {
+			return err
+		}
+	}
+	if l.global != nil {
+		if err := l.global.wait(ctx); err != nil {
+			// The call is not sent, so it must not use up the company's token
+			if company != nil {
+				company.release()
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// observe adapts the most specific bucket for apiKey to a response
+func (l *rateLimiter) observe(apiKey string, resp *http.Response) {
+	b := l.company(apiKey)
+	if b == nil {
+		b = l.global
+	}
+	if b == nil || resp == nil {
+		return
+	}
+
+	now := time.Now()
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests:
+		b.throttle(now, max(retryAfter(resp), rateLimitReset(resp)))
+	case rateLimitExhausted(resp):
+		b.pause(now, rateLimitReset(resp))
+	case resp.StatusCode < 400:
+		b.relax()
+	}
+}
+
+func (l *rateLimiter) stats() RateLimitStats {
+	var stats RateLimitStats
+	if l.global != nil {
+		s := l.global.stats()
+		stats.Global = &s
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if len(l.companies) > 0 {
+		stats.Companies = make(map[string]BucketStats, len(l.companies))
+		for apiKey, b := range l.companies {
+			stats.Companies[redactAPIKey(apiKey)] = b.stats()
+		}
+	}
+	return stats
+}
+
+// RateLimitStats returns the current state of the rate limiter, e.g. for
+// metrics. It is empty when Config.RateLimit is nil.
+func (c *Client) RateLimitStats() RateLimitStats {
+	if c.limiter == nil {
+		return RateLimitStats{}
+	}
+	return c.limiter.stats()
+}
+
+// tokenBucket is a token bucket whose rate adapts to throttling. Tokens may
+// go negative: each caller reserves one and sleeps until its turn.
+type tokenBucket struct {
+	mu          sync.Mutex
+	limit       float64
+	rate        float64
+	burst       float64
+	tokens      float64
+	last        time.Time
+	pausedUntil time.Time
+	waiting     int
+}
+
+func newTokenBucket(limit float64, burst int) *tokenBucket {
+	if burst <= 0 {
+		burst = int(math.Ceil(limit))
+	}
+	return &tokenBucket{
+		limit:  limit,
+		rate:   limit,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// refill adds the tokens accrued since the last update; b.mu must be held
+func (b *tokenBucket) refill(now time.Time) {
+	from := b.last
+	if b.pausedUntil.After(from) {
+		from = b.pausedUntil
+	}
+	if now.After(from) {
+		b.tokens = min(b.burst, b.tokens+now.Sub(from).Seconds()*b.rate)
+	}
+	if now.After(b.last) {
+		b.last = now
+	}
+}
+
+func (b *tokenBucket) wait(ctx context.Context) error {
+	b.mu.Lock()
+	now := time.Now()
+	b.refill(now)
+	b.tokens--
+
+	var delay time.Duration
+	if b.tokens < 0 {
+		delay = time.Duration(-b.tokens / b.rate * float64(time.Second))
+	}
+	if b.pausedUntil.After(now) {
+		delay += b.pausedUntil.Sub(now)
+	}
+	b.waiting++
+	defer func() {
+		b.mu.Lock()
+		b.waiting--
+		b.mu.Unlock()
+	}()
+
+	// A pause that starts while waiting (a 429 for another call) extends it
+	for delay > 0 {
+		b.mu.Unlock()
+		if err := sleep(ctx, delay); err != nil {
+			// Give the reservation back to the callers still waiting
+			b.release()
+			return err
+		}
+		b.mu.Lock()
+		delay = time.Until(b.pausedUntil)
+	}
+	b.mu.Unlock()
+	return nil
+}
+
+// release returns a token reserved by wait for a call that was not sent
+func (b *tokenBucket) release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.tokens = min(b.burst, b.tokens+1)
+}
+
+// throttle halves the rate and pauses the bucket for wait
+func (b *tokenBucket) throttle(now time.Time, wait time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refill(now)
+	b.rate = max(b.rate/2, b.limit*rateLimitFloor)
+	b.tokens = min(b.tokens, 0)
+	if until := now.Add(wait); until.After(b.pausedUntil) {
+		b.pausedUntil = until
+	}
+}
+
+// pause stops the bucket for wait without lowering its rate
+func (b *tokenBucket) pause(now time.Time, wait time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refill(now)
+	b.tokens = min(b.tokens, 0)
+	if until := now.Add(wait); until.After(b.pausedUntil) {
+		b.pausedUntil = until
+	}
+}
+
+// relax raises a throttled rate back toward the limit
+func (b *tokenBucket) relax() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.rate < b.limit {
+		b.refill(time.Now())
+		b.rate = min(b.limit, b.rate+b.limit*rateLimitRecovery)
+	}
+}
+
+func (b *tokenBucket) stats() BucketStats {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	now := time.Now()
+	b.refill(now)
+
+	stats := BucketStats{
+		Limit:       b.limit,
+		Rate:        b.rate,
+		Utilization: min(1, max(0, 1-b.tokens/b.burst)),
+		Waiting:     b.waiting,
+	}
+	if b.pausedUntil.After(now) {
+		stats.PausedUntil = b.pausedUntil
+	}
+	return stats
+}
+
+// rateLimitHeader returns the first of the IETF RateLimit-* or the common
+// X-RateLimit-* header with the given suffix
+func rateLimitHeader(resp *http.Response, suffix string) string {
+	if v := resp.Header.Get("RateLimit-" + suffix); v != "" {
+		return v
+	}
+	return resp.Header.Get("X-RateLimit-" + suffix)
+}
+
+// rateLimitExhausted reports whether the response says no calls remain
+func rateLimitExhausted(resp *http.Response) bool {
+	remaining, err := strconv.Atoi(rateLimitHeader(resp, "Remaining"))
+	return err == nil && remaining <= 0
+}
+
+// rateLimitReset parses the reset header, given in seconds from now or as a
+// Unix timestamp
+func rateLimitReset(resp *http.Response) time.Duration {
+	reset, err := strconv.ParseInt(rateLimitHeader(resp, "Reset"), 10, 64)
+	if err != nil || reset <= 0 {
+		return 0
+	}
+	// Deltas are small; anything past 2001-09-09 is a timestamp
+	if reset >= 1e9 {
+		return time.Until(time.Unix(reset, 0))
+	}
+	return time.Duration(reset) * time.Second
+}
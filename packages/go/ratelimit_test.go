@@ -0,0 +1,118 @@
+package oway
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRateLimit(t *testing.T) {
+	var throttle atomic.Bool
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/v1/auth/token" {
+			w.Write([]byte(`{"accessToken": "test_token", "expiresIn": 3600}`))
+			return
+		}
+		if throttle.Swap(false) {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Write([]byte(`{"orderNumber": "ABC12", "orderStatus": "IN_TRANSIT"}`))
+	}))
+	defer server.Close()
+
+	newClient := func(limit RateLimit) *Client {
+		client, err := New(Config{
+			ClientID:     "client_test",
+			ClientSecret: "secret_test",
+			APIKey:       "oway_sk_default",
+			BaseURL:      server.URL,
+			TokenURL:     server.URL + "/v1/auth/token",
+			Retry:        &RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond},
+			RateLimit:    &limit,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return client
+	}
+	ctx := context.Background()
+
+	t.Run("should space calls at the configured rate", func(t *testing.T) {
+		client := newClient(RateLimit{RequestsPerSecond: 20, Burst: 1})
+		start := time.Now()
+		for i := 0; i < 5; i++ {
+			if _, err := client.TrackShipment(ctx, "ABC12"); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if elapsed := time.Since(start); elapsed < 190*time.Millisecond {
+			t.Errorf("Expected 5 calls at 20/s to take at least 200ms, took %v", elapsed)
+		}
+	})
+
+	t.Run("should stop waiting when context is cancelled", func(t *testing.T) {
+		client := newClient(RateLimit{RequestsPerSecond: 0.1, Burst: 1})
+		if _, err := client.TrackShipment(ctx, "ABC12"); err != nil {
+			t.Fatal(err)
+		}
+
+		ctx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+		defer cancel()
+		if _, err := client.TrackShipment(ctx, "ABC12"); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("Expected context.DeadlineExceeded, got %v", err)
+		}
+	})
+
+	t.Run("should return the company token when the global wait is cancelled", func(t *testing.T) {
+		client := newClient(RateLimit{RequestsPerSecond: 0.1, Burst: 1, PerCompanyRequestsPerSecond: 0.1, PerCompanyBurst: 1})
+		if _, err := client.ForCompany("oway_sk_widgets_987654321").TrackShipment(ctx, "ABC12"); err != nil {
+			t.Fatal(err)
+		}
+
+		ctx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+		defer cancel()
+		if _, err := client.ForCompany("oway_sk_acme_123456789").TrackShipment(ctx, "ABC12"); !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("Expected context.DeadlineExceeded, got %v", err)
+		}
+
+		stats := client.RateLimitStats()
+		if s, ok := stats.Companies["oway_sk_****6789"]; !ok || s.Utilization > 0.5 {
+			t.Errorf("Expected the company token to be returned, got %+v", stats.Companies)
+		}
+	})
+
+	t.Run("should slow down after 429", func(t *testing.T) {
+		client := newClient(RateLimit{RequestsPerSecond: 100})
+		throttle.Store(true)
+		if _, err := client.TrackShipment(ctx, "ABC12"); err != nil {
+			t.Fatal(err)
+		}
+
+		stats := client.RateLimitStats()
+		if stats.Global == nil || stats.Global.Rate >= stats.Global.Limit {
+			t.Errorf("Expected reduced rate after 429, got %+v", stats.Global)
+		}
+	})
+
+	t.Run("should keep a bucket per company", func(t *testing.T) {
+		client := newClient(RateLimit{PerCompanyRequestsPerSecond: 10})
+		client.ForCompany("oway_sk_acme_123456789").TrackShipment(ctx, "ABC12")
+		client.ForCompany("oway_sk_widgets_987654321").TrackShipment(ctx, "ABC12")
+
+		stats := client.RateLimitStats()
+		if stats.Global != nil || len(stats.Companies) != 2 {
+			t.Fatalf("Expected 2 company buckets and no global bucket, got %+v", stats)
+		}
+		if s, ok := stats.Companies["oway_sk_****4321"]; !ok || s.Utilization <= 0 {
+			t.Errorf("Expected redacted key with utilization, got %+v", stats.Companies)
+		}
+	})
+}
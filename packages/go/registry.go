@@ -0,0 +1,148 @@
+package oway
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ErrUnknownTenant is returned when WithCompany names a tenant that has no
+// API key in Config.Companies
+var ErrUnknownTenant = errors.New("no Oway API key registered for tenant")
+
+// KeyProvider loads the mapping from your tenant IDs to Oway company API keys,
+// e.g. from a secrets manager. It is called on every CompanyRegistry reload.
+type KeyProvider interface {
+	LoadKeys(ctx context.Context) (map[string]string, error)
+}
+
+// KeyProviderFunc adapts a function to a KeyProvider
+type KeyProviderFunc func(ctx context.Context) (map[string]string, error)
+
+// LoadKeys implements KeyProvider
+func (f KeyProviderFunc) LoadKeys(ctx context.Context) (map[string]string, error) {
+	return f(ctx)
+}
+
+// StaticKeys returns a KeyProvider for a fixed map of tenant IDs to API keys
+func StaticKeys(keys map[string]string) KeyProvider {
+	return KeyProviderFunc(func(ctx context.Context) (map[string]string, error) {
+		return keys, nil
+	})
+}
+
+// FileKeys returns a KeyProvider that reads a flat tenant-to-key object from a
+// JSON file, or a YAML file when path ends in .yaml or .yml. The file is read
+// again on every reload.
+func FileKeys(path string) KeyProvider {
+	return KeyProviderFunc(func(ctx context.Context) (map[string]string, error) {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		keys := make(map[string]string)
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".yaml", ".yml":
+			err = yaml.Unmarshal(data, &keys)
+		default:
+			err = json.Unmarshal(data, &keys)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("decode %s: %w", path, err)
+		}
+		return keys, nil
+	})
+}
+
+// CompanyRegistry maps your internal tenant IDs to Oway company API keys. Set
+// it as Config.Companies and select a tenant per call with WithCompany. It is
+// safe for concurrent use; Reload and AutoReload swap in new keys without
+// disturbing calls in flight.
+type CompanyRegistry struct {
+	provider KeyProvider
+
+	mu   sync.RWMutex
+	keys map[string]string
+}
+
+// NewCompanyRegistry creates a registry and loads its keys from provider
+func NewCompanyRegistry(ctx context.Context, provider KeyProvider) (*CompanyRegistry, error) {
+	r := &CompanyRegistry{provider: provider}
+	if err := r.Reload(ctx); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Reload loads keys from the provider and replaces the current set. On error
+// the current keys are kept.
+func (r *CompanyRegistry) Reload(ctx context.Context) error {
+	loaded, err := r.provider.LoadKeys(ctx)
+	if err != nil {
+		return fmt.Errorf("load company API keys: %w", err)
+	}
+
+	keys := make(map[string]string, len(loaded))
+	for tenantID, apiKey := range loaded {
+		if apiKey == "" {
+			return fmt.Errorf("load company API keys: empty API key for tenant %q", tenantID)
+		}
+		keys[tenantID] = apiKey
+	}
+
+	r.mu.Lock()
+	r.keys = keys
+	r.mu.Unlock()
+	return nil
+}
+
+// AutoReload calls Reload every interval until ctx is done. onError, if not
+// nil, receives reload failures; the previous keys stay in use.
+func (r *CompanyRegistry) AutoReload(ctx context.Context, interval time.Duration, onError func(error)) {
+	for sleep(ctx, interval) == nil {
+		if err := r.Reload(ctx); err != nil && onError != nil && ctx.Err() == nil {
+			onError(err)
+		}
+	}
+}
+
+// APIKey returns the API key for a tenant, or an error wrapping
+// ErrUnknownTenant
+func (r *CompanyRegistry) APIKey(tenantID string) (string, error) {
+	r.mu.RLock()
+	apiKey, ok := r.keys[tenantID]
+	r.mu.RUnlock()
+	if !ok {
+		return "", fmt.Errorf("%w %q", ErrUnknownTenant, tenantID)
+	}
+	return apiKey, nil
+}
+
+// Tenants returns the registered tenant IDs
+func (r *CompanyRegistry) Tenants() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	tenants := make([]string, 0, len(r.keys))
+	for tenantID := range r.keys {
+		tenants = append(tenants, tenantID)
+	}
+	return tenants
+}
+
+// tenantContextKey is used to pass per-request tenant IDs via context
+type tenantContextKey struct{}
+
+// WithCompany returns a context that calls the API on behalf of a tenant. The
+// tenant's API key is looked up in Config.Companies when the request is sent.
+func WithCompany(ctx context.Context, tenantID string) context.Context {
+	return context.WithValue(ctx, tenantContextKey{}, tenantID)
+}
@@ -0,0 +1,103 @@
+package oway
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+)
+
+func TestCompanyRegistry(t *testing.T) {
+	var lastKey atomic.Value
+	var trackCalls atomic.Int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/v1/auth/token" {
+			w.Write([]byte(`{"accessToken": "test_token", "expiresIn": 3600}`))
+			return
+		}
+		trackCalls.Add(1)
+		lastKey.Store(r.Header.Get("x-oway-api-key"))
+		w.Write([]byte(`{"orderNumber": "ABC12", "orderStatus": "IN_TRANSIT"}`))
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "keys.yaml")
+	if err := os.WriteFile(path, []byte("acme: oway_sk_acme_123\nwidgets: oway_sk_widgets_456\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	registry, err := NewCompanyRegistry(ctx, FileKeys(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client, err := New(Config{
+		ClientID:     "client_test",
+		ClientSecret: "secret_test",
+		APIKey:       "oway_sk_default",
+		BaseURL:      server.URL,
+		TokenURL:     server.URL + "/v1/auth/token",
+		Companies:    registry,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("should send the tenant's API key", func(t *testing.T) {
+		if _, err := client.TrackShipment(WithCompany(ctx, "widgets"), "ABC12"); err != nil {
+			t.Fatal(err)
+		}
+		if lastKey.Load() != "oway_sk_widgets_456" {
+			t.Errorf("Expected widgets key, got %v", lastKey.Load())
+		}
+	})
+
+	t.Run("should fail locally for unknown tenant", func(t *testing.T) {
+		trackCalls.Store(0)
+		_, err := client.TrackShipment(WithCompany(ctx, "globex"), "ABC12")
+		if !errors.Is(err, ErrUnknownTenant) {
+			t.Fatalf("Expected ErrUnknownTenant, got %v", err)
+		}
+		if trackCalls.Load() != 0 {
+			t.Errorf("Expected no request, got %d", trackCalls.Load())
+		}
+	})
+
+	t.Run("should pick up new keys on reload", func(t *testing.T) {
+		if err := os.WriteFile(path, []byte("globex: oway_sk_globex_789\n"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if err := registry.Reload(ctx); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := client.TrackShipment(WithCompany(ctx, "globex"), "ABC12"); err != nil {
+			t.Fatal(err)
+		}
+		if lastKey.Load() != "oway_sk_globex_789" {
+			t.Errorf("Expected globex key, got %v", lastKey.Load())
+		}
+		if _, err := registry.APIKey("acme"); !errors.Is(err, ErrUnknownTenant) {
+			t.Errorf("Expected acme to be removed, got %v", err)
+		}
+	})
+
+	t.Run("should keep keys when reload fails", func(t *testing.T) {
+		if err := os.WriteFile(path, []byte("{not yaml"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if err := registry.Reload(ctx); err == nil {
+			t.Error("Expected reload error")
+		}
+		if _, err := registry.APIKey("globex"); err != nil {
+			t.Errorf("Expected previous keys to remain, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,101 @@
+package oway
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/google/uuid"
+)
+
+// requestIDHeader carries the request ID to and from the Oway API
+const requestIDHeader = "x-request-id"
+
+// requestIDContextKey is used to pass per-request IDs via context
+type requestIDContextKey struct{}
+
+// WithRequestID returns a context with the specified request ID, e.g. your
+// own trace ID. The ID is sent as x-request-id on every attempt of the call
+// and reported in Error.RequestID, RequestError.RequestID and RequestIDs.Sent.
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDContextKey{}, requestID)
+}
+
+// RequestIDs receives the request IDs of a call; see CaptureRequestIDs
+type RequestIDs struct {
+	// Sent is the x-request-id sent with every attempt of the call
+	Sent string
+
+	// Server is the x-request-id returned with the last response, if any
+	Server string
+}
+
+// requestIDsContextKey is used to pass a *RequestIDs via context
+type requestIDsContextKey struct{}
+
+// CaptureRequestIDs returns a context that records the request IDs of the
+// call made with it into ids, including on success. Use a separate
+// RequestIDs for each call.
+func CaptureRequestIDs(ctx context.Context, ids *RequestIDs) context.Context {
+	return context.WithValue(ctx, requestIDsContextKey{}, ids)
+}
+
+// capturedRequestIDs returns the *RequestIDs from context, or nil if unset
+func capturedRequestIDs(ctx context.Context) *RequestIDs {
+	ids, _ := ctx.Value(requestIDsContextKey{}).(*RequestIDs)
+	return ids
+}
+
+// observe records the request ID returned with resp; ids may be nil
+func (ids *RequestIDs) observe(resp *http.Response) {
+	if ids != nil && resp != nil {
+		ids.Server = resp.Header.Get(requestIDHeader)
+	}
+}
+
+// RequestError is returned for calls that fail without an API response, such
+// as transport errors, cancellation or a missing API key. It wraps the cause
+// and carries the request ID the call was sent with.
+type RequestError struct {
+	// RequestID is the x-request-id of the call
+	RequestID string
+
+	// Err is the underlying error
+	Err error
+}
+
+// Error implements the error interface
+func (e *RequestError) Error() string {
+	return fmt.Sprintf("request %s: %v", e.RequestID, e.Err)
+}
+
+// Unwrap returns the underlying error
+func (e *RequestError) Unwrap() error {
+	return e.Err
+}
+
+// requestIDFromContext returns the request ID from context, generating one if unset
+func requestIDFromContext(ctx context.Context) string {
+	if id, ok := ctx.Value(requestIDContextKey{}).(string); ok && id != "" {
+		return id
+	}
+	return uuid.NewString()
+}
+
+// sentRequestID returns the request ID the SDK sent for a response
+func sentRequestID(resp *http.Response) string {
+	if resp.Request == nil {
+		return ""
+	}
+	return resp.Request.Header.Get(requestIDHeader)
+}
+
+// serverRequestID returns the request ID echoed by the server, if it differs
+// from the one the SDK sent
+func serverRequestID(resp *http.Response) string {
+	id := resp.Header.Get(requestIDHeader)
+	if id == sentRequestID(resp) {
+		return ""
+	}
+	return id
+}
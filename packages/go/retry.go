@@ -0,0 +1,155 @@
+package oway
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy controls how the SDK retries transient failures. A response is
+// retried when Error.IsRetryable reports true for its status code, or when
+// the request fails at the network level.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts, including the first (1 disables retries)
+	MaxAttempts int
+
+	// BaseDelay is the delay before the first retry; it doubles on each attempt
+	BaseDelay time.Duration
+
+	// MaxDelay caps the backoff delay. A Retry-After header asking for a
+	// longer wait ends retrying instead.
+	MaxDelay time.Duration
+
+	// Jitter randomizes each delay by up to this fraction (0.0 - 1.0)
+	Jitter float64
+
+	// Operations overrides the policy per operation (e.g. OperationRequestQuote).
+	// Zero fields inherit from the enclosing policy. Mutating operations
+	// (CreateShipment, ConfirmShipment, CancelShipment) are retried only when
+	// they carry an idempotency key or are listed here.
+	Operations map[string]RetryPolicy
+}
+
+// DefaultRetryPolicy returns the policy used when Config.Retry is nil
+func DefaultRetryPolicy() *RetryPolicy {
+	return &RetryPolicy{
+		MaxAttempts: 3,
+		BaseDelay:   500 * time.Millisecond,
+		MaxDelay:    10 * time.Second,
+		Jitter:      0.2,
+	}
+}
+
+// noRetryContextKey marks calls whose caller retries them itself
+type noRetryContextKey struct{}
+
+// withoutRetries disables transport retries for calls made with ctx, so a
+// caller with its own retry loop does not multiply attempts
+func withoutRetries(ctx context.Context) context.Context {
+	return context.WithValue(ctx, noRetryContextKey{}, true)
+}
+
+// forOperation resolves the effective policy for an operation
+func (p *RetryPolicy) forOperation(operation string, idempotent bool) RetryPolicy {
+	base := *p
+	base.Operations = nil
+
+	override, ok := p.Operations[operation]
+	if !ok {
+		if isMutatingOperation(operation) && !idempotent {
+			base.MaxAttempts = 1
+		}
+		return base
+	}
+
+	if override.MaxAttempts != 0 {
+		base.MaxAttempts = override.MaxAttempts
+	}
+	if override.BaseDelay != 0 {
+		base.BaseDelay = override.BaseDelay
+	}
+	if override.MaxDelay != 0 {
+		base.MaxDelay = override.MaxDelay
+	}
+	if override.Jitter != 0 {
+		base.Jitter = override.Jitter
+	}
+	return base
+}
+
+// backoff returns the delay before the given retry (attempt 1 is the first retry)
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	delay := p.BaseDelay
+	for i := 1; i < attempt && delay < p.MaxDelay; i++ {
+		delay *= 2
+	}
+	if p.MaxDelay > 0 && delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+	if p.Jitter > 0 && delay > 0 {
+		spread := float64(delay) * p.Jitter
+		delay += time.Duration(spread * (2*rand.Float64() - 1))
+	}
+	return delay
+}
+
+// shouldRetry reports whether an attempt's outcome is worth retrying
+func shouldRetry(ctx context.Context, resp *http.Response, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	if err != nil {
+		var authErr *AuthError
+		if errors.As(err, &authErr) && !authErr.IsRetryable() {
+			return false
+		}
+		return !errors.Is(err, ErrCircuitOpen)
+	}
+	return (&Error{StatusCode: resp.StatusCode}).IsRetryable()
+}
+
+// retryAfter parses a Retry-After header given in seconds or as an HTTP date
+func retryAfter(resp *http.Response) time.Duration {
+	if resp == nil {
+		return 0
+	}
+	value := resp.Header.Get("Retry-After")
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		return time.Until(date)
+	}
+	return 0
+}
+
+// sleep waits for d or until ctx is done
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// drain discards and closes a response body so the connection can be reused
+func drain(resp *http.Response) {
+	if resp == nil || resp.Body == nil {
+		return
+	}
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	resp.Body.Close()
+}
@@ -0,0 +1,306 @@
+package oway
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Oway-Inc/oway-sdk/packages/go/client"
+)
+
+// tokenExpiryMargin is how close to expiry a cached token stops being sent
+const tokenExpiryMargin = 30 * time.Second
+
+// defaultTokenTTL is assumed when the token response omits expiresIn
+const defaultTokenTTL = 15 * time.Minute
+
+// tokenStoreLockTimeout bounds the wait for another client's refresh
+const tokenStoreLockTimeout = 30 * time.Second
+
+// tokenRetryDelay throttles background refreshes after a failure
+const tokenRetryDelay = 5 * time.Second
+
+// tokenCall is an in-flight token refresh shared by all callers
+type tokenCall struct {
+	done  chan struct{}
+	token string
+	err   error
+}
+
+// startTokenRefresh starts a token refresh, or joins the one in flight
+func (c *Client) startTokenRefresh() *tokenCall {
+	c.tokenCallMutex.Lock()
+	defer c.tokenCallMutex.Unlock()
+	if c.tokenCall != nil {
+		return c.tokenCall
+	}
+
+	call := &tokenCall{done: make(chan struct{})}
+	c.tokenCall = call
+
+	go func() {
+		// Detached from any one caller's context: a caller giving up must not
+		// fail the refresh for everyone else waiting on it
+		ctx := context.Background()
+
+		c.tokenMutex.RLock()
+		currentToken, currentExpiry := c.token, c.tokenExpiry
+		c.tokenMutex.RUnlock()
+
+		token, expiry, err := c.fetchToken(ctx, currentToken, currentExpiry)
+
+		now := time.Now()
+		c.tokenMutex.Lock()
+		if err != nil {
+			c.tokenRetryAt = now.Add(tokenRetryDelay)
+		} else {
+			c.token = token
+			c.tokenExpiry = expiry
+			c.tokenRefreshAt = now.Add(time.Duration(float64(expiry.Sub(now)) * c.config.TokenRefreshFraction))
+		}
+		c.tokenMutex.Unlock()
+
+		c.tokenCallMutex.Lock()
+		c.tokenCall = nil
+		c.tokenCallMutex.Unlock()
+
+		call.token, call.err = token, err
+		close(call.done)
+	}()
+
+	return call
+}
+
+// fetchToken returns a newer token from the TokenStore, or else requests one
+// and stores it. Stores implementing TokenStoreLocker are locked across the
+// check and the request, so only one of the clients sharing them calls the
+// token endpoint.
+func (c *Client) fetchToken(ctx context.Context, currentToken string, currentExpiry time.Time) (string, time.Time, error) {
+	// Prefer a newer token another client already put in the store
+	if token, expiry, ok := c.storedToken(ctx, currentToken, currentExpiry); ok {
+		c.config.Logger.Debug("oway access token loaded from store", "expires_at", expiry)
+		return token, expiry, nil
+	}
+
+	if locker, ok := c.config.TokenStore.(TokenStoreLocker); ok {
+		lockCtx, cancel := context.WithTimeout(ctx, tokenStoreLockTimeout)
+		unlock, err := locker.Lock(lockCtx, c.tokenStoreKey())
+		cancel()
+		if err != nil {
+			// Refreshing without the lock only costs an extra token request
+			c.config.Logger.Warn("oway token store lock failed", "error", err)
+		} else {
+			defer unlock()
+			// Another client may have refreshed while we waited for the lock
+			if token, expiry, ok := c.storedToken(ctx, currentToken, currentExpiry); ok {
+				c.config.Logger.Debug("oway access token loaded from store", "expires_at", expiry)
+				return token, expiry, nil
+			}
+		}
+	}
+
+	start := time.Now()
+	token, expiry, err := c.refreshToken(ctx)
+	if hooks := c.config.Instrumentation; hooks != nil && hooks.OnTokenRefresh != nil {
+		hooks.OnTokenRefresh(ctx, time.Since(start), err)
+	}
+	if err != nil {
+		c.config.Logger.Error("oway access token refresh failed", "error", err)
+		return "", time.Time{}, err
+	}
+	c.config.Logger.Debug("oway access token refreshed", "expires_at", expiry)
+	// A failed write only costs other clients a token request
+	if storeErr := c.config.TokenStore.Set(ctx, c.tokenStoreKey(), token, expiry); storeErr != nil {
+		c.config.Logger.Warn("oway token store write failed", "error", storeErr)
+	}
+	return token, expiry, nil
+}
+
+// invalidateToken drops the cached token after the API rejected it, so the
+// next caller fetches a new one. The token itself is kept to recognize the
+// same token coming back from the TokenStore. Tokens already replaced are
+// left alone.
+func (c *Client) invalidateToken(rejected string) {
+	c.tokenMutex.Lock()
+	defer c.tokenMutex.Unlock()
+	if c.token != rejected {
+		return
+	}
+	c.tokenExpiry = time.Time{}
+	c.tokenRefreshAt = time.Time{}
+	c.tokenRetryAt = time.Time{}
+}
+
+// tokenRefresher renews the access token in the background
+type tokenRefresher struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// Start fetches an access token and keeps it fresh in the background, so
+// requests never wait on the token endpoint. Failed refreshes are retried
+// with backoff while the current token remains valid. Call Stop to end it.
+func (c *Client) Start(ctx context.Context) error {
+	c.refresherMutex.Lock()
+	defer c.refresherMutex.Unlock()
+	if c.refresher != nil {
+		return fmt.Errorf("background token refresh already started")
+	}
+
+	if _, err := c.getAccessToken(ctx); err != nil {
+		return err
+	}
+
+	loopCtx, cancel := context.WithCancel(context.Background())
+	c.refresher = &tokenRefresher{cancel: cancel, done: make(chan struct{})}
+	go c.refreshLoop(loopCtx, c.refresher.done)
+	return nil
+}
+
+// Stop ends background token refresh started by Start
+func (c *Client) Stop() {
+	c.refresherMutex.Lock()
+	defer c.refresherMutex.Unlock()
+	if c.refresher == nil {
+		return
+	}
+	c.refresher.cancel()
+	<-c.refresher.done
+	c.refresher = nil
+}
+
+func (c *Client) refreshLoop(ctx context.Context, done chan struct{}) {
+	defer close(done)
+
+	const minBackoff, maxBackoff = time.Second, time.Minute
+	backoff := minBackoff
+
+	for {
+		c.tokenMutex.RLock()
+		refreshAt := c.tokenRefreshAt
+		c.tokenMutex.RUnlock()
+
+		if sleep(ctx, time.Until(refreshAt)) != nil {
+			return
+		}
+
+		call := c.startTokenRefresh()
+		select {
+		case <-call.done:
+		case <-ctx.Done():
+			return
+		}
+		if call.err == nil {
+			backoff = minBackoff
+			continue
+		}
+
+		if sleep(ctx, backoff) != nil {
+			return
+		}
+		backoff = min(backoff*2, maxBackoff)
+	}
+}
+
+// refreshToken fetches a new access token, retrying transient failures under
+// Config.Retry. Failures are returned as *AuthError.
+func (c *Client) refreshToken(ctx context.Context) (string, time.Time, error) {
+	policy := c.config.Retry.forOperation(OperationGetToken, true)
+
+	for attempt := 1; ; attempt++ {
+		token, expiry, wait, err := c.requestToken(ctx)
+		if err == nil {
+			return token, expiry, nil
+		}
+		if attempt >= policy.MaxAttempts || ctx.Err() != nil || !err.IsRetryable() {
+			return "", time.Time{}, err
+		}
+
+		delay := policy.backoff(attempt)
+		if wait > 0 {
+			if policy.MaxDelay > 0 && wait > policy.MaxDelay {
+				return "", time.Time{}, err
+			}
+			delay = wait
+		}
+		c.config.Logger.Warn("retrying oway token request", "attempt", attempt, "delay", delay, "error", err)
+		if sleepErr := sleep(ctx, delay); sleepErr != nil {
+			return "", time.Time{}, &AuthError{Err: sleepErr}
+		}
+	}
+}
+
+// requestToken makes a single token request. wait is the server's
+// Retry-After, if any.
+func (c *Client) requestToken(ctx context.Context) (token string, expiry time.Time, wait time.Duration, authErr *AuthError) {
+	reqBody, _ := json.Marshal(client.TokenRequest{
+		ClientId:     c.config.ClientID,
+		ClientSecret: c.config.ClientSecret,
+	})
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.config.TokenURL, bytes.NewReader(reqBody))
+	if err != nil {
+		return "", time.Time{}, 0, &AuthError{Err: err}
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.config.HTTPClient.Do(req)
+	if err != nil {
+		return "", time.Time{}, 0, &AuthError{Err: err}
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", time.Time{}, 0, &AuthError{StatusCode: resp.StatusCode, Err: err}
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return "", time.Time{}, retryAfter(resp), newAuthError(resp.StatusCode, body)
+	}
+
+	var tokenResp client.TokenResponse
+	if err := json.Unmarshal(body, &tokenResp); err != nil {
+		return "", time.Time{}, 0, &AuthError{StatusCode: resp.StatusCode, Err: fmt.Errorf("invalid token response: %w", err)}
+	}
+	if tokenResp.AccessToken == nil || *tokenResp.AccessToken == "" {
+		return "", time.Time{}, 0, &AuthError{StatusCode: resp.StatusCode, Err: fmt.Errorf("invalid token response: missing accessToken")}
+	}
+	if tokenResp.TokenType != nil && *tokenResp.TokenType != "" && !strings.EqualFold(*tokenResp.TokenType, "Bearer") {
+		return "", time.Time{}, 0, &AuthError{StatusCode: resp.StatusCode, Err: fmt.Errorf("invalid token response: unsupported tokenType %q", *tokenResp.TokenType)}
+	}
+
+	ttl := defaultTokenTTL
+	if tokenResp.ExpiresIn != nil && *tokenResp.ExpiresIn > 0 {
+		ttl = time.Duration(*tokenResp.ExpiresIn) * time.Second
+	}
+	return *tokenResp.AccessToken, time.Now().Add(ttl), 0, nil
+}
+
+// newAuthError converts a non-200 token response into an *AuthError,
+// decoding the TokenErrorResponse body when present
+func newAuthError(statusCode int, body []byte) *AuthError {
+	e := &AuthError{StatusCode: statusCode}
+
+	var tokenErr client.TokenErrorResponse
+	if json.Unmarshal(body, &tokenErr) == nil && (tokenErr.Error != nil || tokenErr.ErrorDescription != nil) {
+		e.Code = stringValue(tokenErr.Error)
+		e.Description = stringValue(tokenErr.ErrorDescription)
+		return e
+	}
+
+	// Not a TokenErrorResponse, e.g. an HTML page from a proxy
+	e.Description = strings.TrimSpace(string(body))
+	if len(e.Description) > 200 {
+		e.Description = e.Description[:200]
+	}
+	if e.Description == "" {
+		e.Description = http.StatusText(statusCode)
+	}
+	return e
+}
@@ -0,0 +1,250 @@
+package oway
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// TokenStore persists M2M access tokens so several clients, or several
+// processes, can share one token instead of each calling the token endpoint.
+// Entries are keyed by client ID and token URL. Get returns an empty token
+// when nothing is stored under key.
+type TokenStore interface {
+	Get(ctx context.Context, key string) (token string, expiry time.Time, err error)
+	Set(ctx context.Context, key, token string, expiry time.Time) error
+}
+
+// TokenStoreLocker is implemented by a TokenStore that can serialize token
+// refreshes. A client holds the lock for key from checking the store, through
+// the token request, until the new token is Set, so clients sharing the store
+// make one token request between them. Lock blocks until the lock is held or
+// ctx is done; the caller must call unlock.
+type TokenStoreLocker interface {
+	Lock(ctx context.Context, key string) (unlock func(), err error)
+}
+
+// tokenStoreKey identifies the token for a client's credentials and environment
+func (c *Client) tokenStoreKey() string {
+	return c.config.ClientID + "@" + c.config.TokenURL
+}
+
+// storedToken returns a token from the store that differs from and expires
+// later than the cached one, if there is one
+func (c *Client) storedToken(ctx context.Context, currentToken string, currentExpiry time.Time) (string, time.Time, bool) {
+	token, expiry, err := c.config.TokenStore.Get(ctx, c.tokenStoreKey())
+	if err != nil || token == "" || token == currentToken {
+		return "", time.Time{}, false
+	}
+	if !time.Now().Add(tokenExpiryMargin).Before(expiry) || !expiry.After(currentExpiry) {
+		return "", time.Time{}, false
+	}
+	return token, expiry, true
+}
+
+// MemoryTokenStore is an in-process TokenStore. It is the default, and can be
+// shared between clients in one process through Config.TokenStore.
+type MemoryTokenStore struct {
+	mu         sync.RWMutex
+	tokens     map[string]memoryToken
+	refreshing map[string]chan struct{}
+}
+
+type memoryToken struct {
+	token  string
+	expiry time.Time
+}
+
+// NewMemoryTokenStore creates an empty in-memory token store
+func NewMemoryTokenStore() *MemoryTokenStore {
+	return &MemoryTokenStore{tokens: make(map[string]memoryToken), refreshing: make(map[string]chan struct{})}
+}
+
+// Get implements TokenStore
+func (s *MemoryTokenStore) Get(ctx context.Context, key string) (string, time.Time, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	t := s.tokens[key]
+	return t.token, t.expiry, nil
+}
+
+// Set implements TokenStore
+func (s *MemoryTokenStore) Set(ctx context.Context, key, token string, expiry time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tokens[key] = memoryToken{token: token, expiry: expiry}
+	return nil
+}
+
+// Lock implements TokenStoreLocker
+func (s *MemoryTokenStore) Lock(ctx context.Context, key string) (func(), error) {
+	s.mu.Lock()
+	sem, ok := s.refreshing[key]
+	if !ok {
+		sem = make(chan struct{}, 1)
+		s.refreshing[key] = sem
+	}
+	s.mu.Unlock()
+
+	select {
+	case sem <- struct{}{}:
+		return func() { <-sem }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// FileTokenStore is a TokenStore backed by a JSON file, for workers on one
+// host sharing a token. Access is serialized with an advisory lock on a
+// sibling ".lock" file and writes replace the file atomically. Refreshes are
+// serialized across processes with a second lock on a ".refresh" file. The file holds
+// live credentials and is created with mode 0600.
+type FileTokenStore struct {
+	path string
+}
+
+// fileToken is one entry of a FileTokenStore file
+type fileToken struct {
+	AccessToken string    `json:"accessToken"`
+	Expiry      time.Time `json:"expiry"`
+}
+
+// NewFileTokenStore creates a token store at path. The file and its
+// directory are created on the first Set.
+func NewFileTokenStore(path string) *FileTokenStore {
+	return &FileTokenStore{path: path}
+}
+
+// Get implements TokenStore
+func (s *FileTokenStore) Get(ctx context.Context, key string) (string, time.Time, error) {
+	var t fileToken
+	err := s.withLock(false, func() error {
+		tokens, err := s.read()
+		t = tokens[key]
+		return err
+	})
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return t.AccessToken, t.Expiry, nil
+}
+
+// Set implements TokenStore
+func (s *FileTokenStore) Set(ctx context.Context, key, token string, expiry time.Time) error {
+	return s.withLock(true, func() error {
+		tokens, err := s.read()
+		if err != nil {
+			return err
+		}
+
+		// Drop expired entries so the file does not grow without bound
+		now := time.Now()
+		for k, t := range tokens {
+			if now.After(t.Expiry) {
+				delete(tokens, k)
+			}
+		}
+		tokens[key] = fileToken{AccessToken: token, Expiry: expiry}
+
+		return s.write(tokens)
+	})
+}
+
+// tokenStorePollInterval is how often FileTokenStore.Lock retries a held lock
+const tokenStorePollInterval = 10 * time.Millisecond
+
+// Lock implements TokenStoreLocker with an advisory lock shared by all keys
+func (s *FileTokenStore) Lock(ctx context.Context, key string) (func(), error) {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o700); err != nil {
+		return nil, fmt.Errorf("token store: %w", err)
+	}
+	lock, err := os.OpenFile(s.path+".refresh", os.O_CREATE|os.O_RDWR, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("token store: %w", err)
+	}
+
+	for {
+		locked, err := tryLockFile(lock)
+		if err != nil {
+			lock.Close()
+			return nil, fmt.Errorf("token store: lock %s: %w", lock.Name(), err)
+		}
+		if locked {
+			return func() {
+				unlockFile(lock)
+				lock.Close()
+			}, nil
+		}
+		if err := sleep(ctx, tokenStorePollInterval); err != nil {
+			lock.Close()
+			return nil, err
+		}
+	}
+}
+
+// withLock runs fn holding a shared or exclusive lock on the store
+func (s *FileTokenStore) withLock(exclusive bool, fn func() error) error {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o700); err != nil {
+		return fmt.Errorf("token store: %w", err)
+	}
+	lock, err := os.OpenFile(s.path+".lock", os.O_CREATE|os.O_RDWR, 0o600)
+	if err != nil {
+		return fmt.Errorf("token store: %w", err)
+	}
+	defer lock.Close()
+
+	if err := lockFile(lock, exclusive); err != nil {
+		return fmt.Errorf("token store: lock %s: %w", lock.Name(), err)
+	}
+	defer unlockFile(lock)
+
+	return fn()
+}
+
+func (s *FileTokenStore) read() (map[string]fileToken, error) {
+	tokens := make(map[string]fileToken)
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return tokens, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("token store: %w", err)
+	}
+	if len(data) == 0 {
+		return tokens, nil
+	}
+	if err := json.Unmarshal(data, &tokens); err != nil {
+		return nil, fmt.Errorf("token store: decode %s: %w", s.path, err)
+	}
+	return tokens, nil
+}
+
+func (s *FileTokenStore) write(tokens map[string]fileToken) error {
+	data, err := json.Marshal(tokens)
+	if err != nil {
+		return fmt.Errorf("token store: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".tmp*")
+	if err != nil {
+		return fmt.Errorf("token store: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("token store: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("token store: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("token store: %w", err)
+	}
+	return nil
+}
@@ -18,6 +18,20 @@ type (
 	Invoice  = client.InvoiceResponse
 )
 
+// Carrier types
+type (
+	CarrierConfig = client.CarrierApiConfigResponse
+	GpsData       = client.GpsData
+	TripRequest   = client.TripRequest
+	TripLeg       = client.TripLeg
+	Job           = client.OfferWithOrderDataDTO
+	GetJobsParams = client.GetJobsParams
+
+	// Carrier types under their OpenAPI schema names
+	CarrierApiConfigResponse = client.CarrierApiConfigResponse
+	OfferWithOrderDataDTO    = client.OfferWithOrderDataDTO
+)
+
 // Common types
 type (
 	Address        = client.Address
@@ -26,6 +40,24 @@ type (
 	DocumentType   = client.GetDocumentParamsDocumentType
 )
 
+// Status types
+type (
+	ShipmentOrderStatus = client.ShipmentOrderStatus
+	TrackingOrderStatus = client.TrackingOrderStatus
+)
+
+// Tracking status constants
+const (
+	TrackingStatusInitialized TrackingOrderStatus = "INITIALIZED"
+	TrackingStatusConfirmed   TrackingOrderStatus = "CONFIRMED"
+	TrackingStatusAccepted    TrackingOrderStatus = "ACCEPTED"
+	TrackingStatusAssigned    TrackingOrderStatus = "ASSIGNED"
+	TrackingStatusPickedUp    TrackingOrderStatus = "PICKED_UP"
+	TrackingStatusInTransit   TrackingOrderStatus = "IN_TRANSIT"
+	TrackingStatusDelivered   TrackingOrderStatus = "DELIVERED"
+	TrackingStatusCancelled   TrackingOrderStatus = "CANCELLED"
+)
+
 // Document type constants
 const (
 	DocumentTypeBOL           DocumentType = "BILL_OF_LADING"
@@ -0,0 +1,205 @@
+// Package validate checks quote and shipment requests offline against the
+// constraints of the Oway API, reporting every invalid field at once instead
+// of one 400/422 round trip at a time. Set Config.ValidateRequests to run it
+// automatically in RequestQuote and CreateShipment.
+package validate
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/Oway-Inc/oway-sdk/packages/go/client"
+)
+
+// ErrInvalid is matched (via errors.Is) by every *ValidationError
+var ErrInvalid = errors.New("invalid request")
+
+// FieldError describes one invalid field
+type FieldError struct {
+	// Field is the JSON path of the field (e.g. "pickupAddress.zipCode" or
+	// "orderComponents[1].palletDimensions")
+	Field string
+
+	// Message says what is wrong with it
+	Message string
+}
+
+// Error implements the error interface
+func (e FieldError) Error() string {
+	return e.Field + ": " + e.Message
+}
+
+// ValidationError lists every invalid field of a request
+type ValidationError struct {
+	Fields []FieldError
+}
+
+// Error implements the error interface
+func (e *ValidationError) Error() string {
+	messages := make([]string, len(e.Fields))
+	for i, field := range e.Fields {
+		messages[i] = field.Error()
+	}
+	return fmt.Sprintf("%s: %s", ErrInvalid, strings.Join(messages, "; "))
+}
+
+// Is reports whether target is ErrInvalid
+func (e *ValidationError) Is(target error) bool {
+	return target == ErrInvalid
+}
+
+// Field patterns from the OpenAPI spec
+var (
+	statePattern = regexp.MustCompile(`^[A-Z]{2}$`)
+	zipPattern   = regexp.MustCompile(`^\d{5}$`)
+	phonePattern = regexp.MustCompile(`^\+[1-9]\d{1,14}$`)
+	timePattern  = regexp.MustCompile(`^([01]?[0-9]|2[0-3]):[0-5][0-9]$`)
+)
+
+// Location hours the API assumes when OpenTime or CloseTime is omitted
+const (
+	defaultOpenTime  = "10:00"
+	defaultCloseTime = "16:00"
+)
+
+// QuoteRequest validates a request for RequestQuote
+func QuoteRequest(req *client.QuoteRequest) error {
+	if req == nil {
+		return &ValidationError{Fields: []FieldError{{Field: "request", Message: "is required"}}}
+	}
+	var v validator
+	v.address("pickupAddress", req.PickupAddress)
+	v.address("deliveryAddress", req.DeliveryAddress)
+	v.orderComponents("orderComponents", req.OrderComponents)
+	return v.err()
+}
+
+// ShipmentRequest validates a request for CreateShipment
+func ShipmentRequest(req *client.CreateShipmentRequest) error {
+	if req == nil {
+		return &ValidationError{Fields: []FieldError{{Field: "request", Message: "is required"}}}
+	}
+	var v validator
+	v.address("pickupAddress", req.PickupAddress)
+	v.address("deliveryAddress", req.DeliveryAddress)
+	v.orderComponents("orderComponents", req.OrderComponents)
+	v.required("description", req.Description)
+	if req.RequiredPickupDate != nil && req.RequiredDeliveryBy != nil && req.RequiredDeliveryBy.Before(*req.RequiredPickupDate) {
+		v.add("requiredDeliveryBy", "must not be before requiredPickupDate")
+	}
+	return v.err()
+}
+
+// Address validates a pickup or delivery address
+func Address(addr client.Address) error {
+	var v validator
+	v.address("", addr)
+	return v.err()
+}
+
+// OrderComponent validates a cargo component
+func OrderComponent(component client.OrderComponent) error {
+	var v validator
+	v.orderComponent("", component)
+	return v.err()
+}
+
+// validator collects field errors
+type validator struct {
+	fields []FieldError
+}
+
+func (v *validator) add(field, message string) {
+	v.fields = append(v.fields, FieldError{Field: field, Message: message})
+}
+
+func (v *validator) err() error {
+	if len(v.fields) == 0 {
+		return nil
+	}
+	return &ValidationError{Fields: v.fields}
+}
+
+// path joins a field name onto a prefix
+func path(prefix, field string) string {
+	if prefix == "" {
+		return field
+	}
+	return prefix + "." + field
+}
+
+func (v *validator) required(field, value string) bool {
+	if strings.TrimSpace(value) == "" {
+		v.add(field, "is required")
+		return false
+	}
+	return true
+}
+
+func (v *validator) pattern(field, value string, pattern *regexp.Regexp, message string) {
+	if v.required(field, value) && !pattern.MatchString(value) {
+		v.add(field, message)
+	}
+}
+
+func (v *validator) address(prefix string, addr client.Address) {
+	v.required(path(prefix, "name"), addr.Name)
+	v.required(path(prefix, "address1"), addr.Address1)
+	v.required(path(prefix, "city"), addr.City)
+	v.required(path(prefix, "contactPerson"), addr.ContactPerson)
+	v.pattern(path(prefix, "state"), addr.State, statePattern, "must be a two-letter uppercase state code")
+	v.pattern(path(prefix, "zipCode"), addr.ZipCode, zipPattern, "must be a 5-digit ZIP code")
+	v.pattern(path(prefix, "phoneNumber"), addr.PhoneNumber, phonePattern, "must be in E.164 format (e.g. +15125550100)")
+
+	openTime, openOK := v.clockTime(path(prefix, "openTime"), addr.OpenTime, defaultOpenTime)
+	closeTime, closeOK := v.clockTime(path(prefix, "closeTime"), addr.CloseTime, defaultCloseTime)
+	if openOK && closeOK && openTime >= closeTime {
+		v.add(path(prefix, "openTime"), fmt.Sprintf("must be before closeTime (%s)", formatMinutes(closeTime)))
+	}
+}
+
+// clockTime parses an optional HH:mm time into minutes after midnight
+func (v *validator) clockTime(field string, value *string, fallback string) (int, bool) {
+	s := fallback
+	if value != nil {
+		s = *value
+	}
+	if !timePattern.MatchString(s) {
+		v.add(field, "must be a 24-hour time (HH:mm)")
+		return 0, false
+	}
+	var hours, minutes int
+	fmt.Sscanf(s, "%d:%d", &hours, &minutes)
+	return hours*60 + minutes, true
+}
+
+func formatMinutes(minutes int) string {
+	return fmt.Sprintf("%02d:%02d", minutes/60, minutes%60)
+}
+
+func (v *validator) orderComponents(field string, components []client.OrderComponent) {
+	if len(components) == 0 {
+		v.add(field, "must contain at least one component")
+		return
+	}
+	for i, component := range components {
+		v.orderComponent(fmt.Sprintf("%s[%d]", field, i), component)
+	}
+}
+
+func (v *validator) orderComponent(prefix string, component client.OrderComponent) {
+	if component.PalletCount <= 0 {
+		v.add(path(prefix, "palletCount"), "must be positive")
+	}
+	if component.PoundsWeight <= 0 {
+		v.add(path(prefix, "poundsWeight"), "must be positive")
+	}
+	switch {
+	case len(component.PalletDimensions) != 3:
+		v.add(path(prefix, "palletDimensions"), fmt.Sprintf("must be [height, length, width], got %d values", len(component.PalletDimensions)))
+	case component.PalletDimensions[0] <= 0 || component.PalletDimensions[1] <= 0 || component.PalletDimensions[2] <= 0:
+		v.add(path(prefix, "palletDimensions"), "must be positive")
+	}
+}
@@ -0,0 +1,145 @@
+package validate
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/Oway-Inc/oway-sdk/packages/go/client"
+)
+
+func validAddress() client.Address {
+	return client.Address{
+		Name:          "Warehouse",
+		Address1:      "1 Main St",
+		City:          "Austin",
+		State:         "TX",
+		ZipCode:       "78701",
+		PhoneNumber:   "+15125550100",
+		ContactPerson: "Pat",
+	}
+}
+
+func validComponent() client.OrderComponent {
+	return client.OrderComponent{PalletCount: 2, PalletDimensions: []int32{48, 40, 48}, PoundsWeight: 500}
+}
+
+// fields returns the invalid fields reported by err
+func fields(t *testing.T, err error) []string {
+	t.Helper()
+	if err == nil {
+		return nil
+	}
+	var verr *ValidationError
+	if !errors.As(err, &verr) || !errors.Is(err, ErrInvalid) {
+		t.Fatalf("Expected *ValidationError, got %v", err)
+	}
+	var names []string
+	for _, f := range verr.Fields {
+		names = append(names, f.Field)
+	}
+	return names
+}
+
+func strPtr(s string) *string { return &s }
+
+func TestAddress(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(*client.Address)
+		want   []string
+	}{
+		{"valid", func(a *client.Address) {}, nil},
+		{"empty contact person", func(a *client.Address) { a.ContactPerson = " " }, []string{"contactPerson"}},
+		{"three-letter state", func(a *client.Address) { a.State = "TEX" }, []string{"state"}},
+		{"malformed ZIP", func(a *client.Address) { a.ZipCode = "7870" }, []string{"zipCode"}},
+		{"phone not E.164", func(a *client.Address) { a.PhoneNumber = "512-555-0100" }, []string{"phoneNumber"}},
+		{"open after close", func(a *client.Address) { a.OpenTime, a.CloseTime = strPtr("15:00"), strPtr("09:00") }, []string{"openTime"}},
+		{"open after default close", func(a *client.Address) { a.OpenTime = strPtr("17:00") }, []string{"openTime"}},
+		{"malformed time", func(a *client.Address) { a.CloseTime = strPtr("5pm") }, []string{"closeTime"}},
+		{"several fields", func(a *client.Address) { a.Name, a.City = "", "" }, []string{"name", "city"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			addr := validAddress()
+			tt.modify(&addr)
+			if got := fields(t, Address(addr)); !slices.Equal(got, tt.want) {
+				t.Errorf("Expected invalid fields %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestOrderComponent(t *testing.T) {
+	tests := []struct {
+		name      string
+		component client.OrderComponent
+		want      []string
+	}{
+		{"valid", validComponent(), nil},
+		{"two dimensions", client.OrderComponent{PalletCount: 1, PalletDimensions: []int32{48, 40}, PoundsWeight: 500}, []string{"palletDimensions"}},
+		{"zero dimension", client.OrderComponent{PalletCount: 1, PalletDimensions: []int32{48, 0, 40}, PoundsWeight: 500}, []string{"palletDimensions"}},
+		{"zero weight and count", client.OrderComponent{PalletDimensions: []int32{48, 40, 48}}, []string{"palletCount", "poundsWeight"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := fields(t, OrderComponent(tt.component)); !slices.Equal(got, tt.want) {
+				t.Errorf("Expected invalid fields %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestRequests(t *testing.T) {
+	t.Run("should report every invalid field of a quote request", func(t *testing.T) {
+		delivery := validAddress()
+		delivery.ZipCode = "ABCDE"
+		req := &client.QuoteRequest{
+			PickupAddress:   validAddress(),
+			DeliveryAddress: delivery,
+			OrderComponents: []client.OrderComponent{validComponent(), {PalletCount: 1, PalletDimensions: []int32{48}}},
+		}
+		want := []string{"deliveryAddress.zipCode", "orderComponents[1].poundsWeight", "orderComponents[1].palletDimensions"}
+		if got := fields(t, QuoteRequest(req)); !slices.Equal(got, want) {
+			t.Errorf("Expected invalid fields %v, got %v", want, got)
+		}
+	})
+
+	t.Run("should require order components", func(t *testing.T) {
+		req := &client.QuoteRequest{PickupAddress: validAddress(), DeliveryAddress: validAddress()}
+		if got := fields(t, QuoteRequest(req)); !slices.Equal(got, []string{"orderComponents"}) {
+			t.Errorf("Expected orderComponents to be invalid, got %v", got)
+		}
+	})
+
+	t.Run("should check shipment description and dates", func(t *testing.T) {
+		pickup := time.Date(2026, 4, 2, 8, 0, 0, 0, time.UTC)
+		deliverBy := pickup.Add(-24 * time.Hour)
+		req := &client.CreateShipmentRequest{
+			PickupAddress:      validAddress(),
+			DeliveryAddress:    validAddress(),
+			OrderComponents:    []client.OrderComponent{validComponent()},
+			RequiredPickupDate: &pickup,
+			RequiredDeliveryBy: &deliverBy,
+		}
+		want := []string{"description", "requiredDeliveryBy"}
+		if got := fields(t, ShipmentRequest(req)); !slices.Equal(got, want) {
+			t.Errorf("Expected invalid fields %v, got %v", want, got)
+		}
+
+		req.Description = "Electronics"
+		deliverBy = pickup.Add(48 * time.Hour)
+		if err := ShipmentRequest(req); err != nil {
+			t.Errorf("Expected valid request, got %v", err)
+		}
+	})
+
+	t.Run("should reject nil requests", func(t *testing.T) {
+		if !errors.Is(QuoteRequest(nil), ErrInvalid) || !errors.Is(ShipmentRequest(nil), ErrInvalid) {
+			t.Error("Expected nil requests to be invalid")
+		}
+	})
+}
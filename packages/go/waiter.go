@@ -0,0 +1,116 @@
+package oway
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/Oway-Inc/oway-sdk/packages/go/lifecycle"
+)
+
+// ErrTerminalStatus is returned by WaitForStatus when the shipment reaches a
+// terminal status (DELIVERED or CANCELLED) that is not one of the targets
+var ErrTerminalStatus = errors.New("shipment reached a terminal status")
+
+// WaitOptions controls how WaitForStatus polls TrackShipment
+type WaitOptions struct {
+	// PollInterval is the delay between polls (default: 30 seconds)
+	PollInterval time.Duration
+
+	// MaxPollInterval caps the delay as it backs off (default: 5 minutes)
+	MaxPollInterval time.Duration
+
+	// Multiplier grows the delay after each poll without a status change;
+	// 1 polls at a fixed interval (default: 1.5)
+	Multiplier float64
+}
+
+// StatusTransition is a status change observed while waiting
+type StatusTransition struct {
+	// From is the previous status ("" for the first observation)
+	From TrackingOrderStatus
+
+	// To is the new status
+	To TrackingOrderStatus
+
+	// ObservedAt is when the SDK observed the change
+	ObservedAt time.Time
+}
+
+// WaitResult is the outcome of WaitForStatus
+type WaitResult struct {
+	// Tracking is the last tracking snapshot received
+	Tracking *Tracking
+
+	// History lists the status transitions observed, oldest first
+	History []StatusTransition
+}
+
+// IsTerminalStatus returns true if no further status changes are expected
+func IsTerminalStatus(status TrackingOrderStatus) bool {
+	return lifecycle.IsTerminal(lifecycle.Status(status))
+}
+
+// WaitForStatus polls TrackShipment until the shipment reaches one of the
+// target statuses, using the default WaitOptions
+func (c *Client) WaitForStatus(ctx context.Context, orderNumber string, target ...TrackingOrderStatus) (*WaitResult, error) {
+	return c.WaitForStatusWithOptions(ctx, orderNumber, WaitOptions{}, target...)
+}
+
+// WaitForStatusWithOptions polls TrackShipment until the shipment reaches one
+// of the target statuses. It returns ErrTerminalStatus if the shipment reaches
+// DELIVERED or CANCELLED without matching a target, and ctx.Err() if ctx is
+// done first. The result holds the history observed so far in every case.
+func (c *Client) WaitForStatusWithOptions(ctx context.Context, orderNumber string, opts WaitOptions, target ...TrackingOrderStatus) (*WaitResult, error) {
+	if len(target) == 0 {
+		return nil, fmt.Errorf("at least one target status is required")
+	}
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = 30 * time.Second
+	}
+	if opts.MaxPollInterval <= 0 {
+		opts.MaxPollInterval = 5 * time.Minute
+	}
+	if opts.Multiplier < 1 {
+		opts.Multiplier = 1.5
+	}
+
+	result := &WaitResult{}
+	var current TrackingOrderStatus
+	interval := opts.PollInterval
+
+	for {
+		tracking, err := c.TrackShipment(ctx, orderNumber)
+		if err != nil {
+			var apiErr *Error
+			if !errors.As(err, &apiErr) || !apiErr.IsRetryable() {
+				return result, err
+			}
+		} else {
+			result.Tracking = tracking
+			if tracking.OrderStatus != nil && *tracking.OrderStatus != current {
+				result.History = append(result.History, StatusTransition{
+					From:       current,
+					To:         *tracking.OrderStatus,
+					ObservedAt: time.Now(),
+				})
+				current = *tracking.OrderStatus
+				interval = opts.PollInterval
+
+				if slices.Contains(target, current) {
+					return result, nil
+				}
+				if IsTerminalStatus(current) {
+					return result, fmt.Errorf("%w: order %s is %s", ErrTerminalStatus, orderNumber, current)
+				}
+			}
+		}
+
+		if err := sleep(ctx, interval); err != nil {
+			return result, err
+		}
+		interval = min(time.Duration(float64(interval)*opts.Multiplier), opts.MaxPollInterval)
+	}
+}
@@ -0,0 +1,87 @@
+package oway
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWaitForStatus(t *testing.T) {
+	statuses := map[string][]TrackingOrderStatus{
+		"ABC12": {"CONFIRMED", "CONFIRMED", "ASSIGNED", "PICKED_UP", "IN_TRANSIT"},
+		"XYZ99": {"CONFIRMED", "CANCELLED"},
+	}
+	polls := map[string]*atomic.Int32{"ABC12": {}, "XYZ99": {}}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/v1/auth/token" {
+			w.Write([]byte(`{"accessToken": "test_token", "expiresIn": 3600}`))
+			return
+		}
+
+		var orderNumber string
+		fmt.Sscanf(r.URL.Path, "/v1/shipper/shipment/%5s/tracking", &orderNumber)
+		sequence := statuses[orderNumber]
+		n := int(polls[orderNumber].Add(1)) - 1
+		status := sequence[min(n, len(sequence)-1)]
+		fmt.Fprintf(w, `{"orderNumber": %q, "orderStatus": %q}`, orderNumber, status)
+	}))
+	defer server.Close()
+
+	client, err := New(Config{
+		ClientID:     "client_test",
+		ClientSecret: "secret_test",
+		BaseURL:      server.URL,
+		TokenURL:     server.URL + "/v1/auth/token",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	opts := WaitOptions{PollInterval: time.Millisecond, Multiplier: 1}
+
+	t.Run("should wait for target status", func(t *testing.T) {
+		result, err := client.WaitForStatusWithOptions(context.Background(), "ABC12", opts, TrackingStatusPickedUp)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if *result.Tracking.OrderStatus != TrackingStatusPickedUp {
+			t.Errorf("Expected PICKED_UP, got %s", *result.Tracking.OrderStatus)
+		}
+
+		want := []TrackingOrderStatus{"CONFIRMED", "ASSIGNED", "PICKED_UP"}
+		if len(result.History) != len(want) {
+			t.Fatalf("Expected %d transitions, got %+v", len(want), result.History)
+		}
+		for i, transition := range result.History {
+			if transition.To != want[i] {
+				t.Errorf("Transition %d: expected %s, got %s", i, want[i], transition.To)
+			}
+		}
+	})
+
+	t.Run("should stop on terminal status", func(t *testing.T) {
+		result, err := client.WaitForStatusWithOptions(context.Background(), "XYZ99", opts, TrackingStatusDelivered)
+		if !errors.Is(err, ErrTerminalStatus) {
+			t.Fatalf("Expected ErrTerminalStatus, got %v", err)
+		}
+		if last := result.History[len(result.History)-1]; last.To != TrackingStatusCancelled {
+			t.Errorf("Expected last transition to CANCELLED, got %s", last.To)
+		}
+	})
+
+	t.Run("should honor context deadline", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		_, err := client.WaitForStatusWithOptions(ctx, "ABC12", opts, TrackingStatusDelivered)
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("Expected context.DeadlineExceeded, got %v", err)
+		}
+	})
+}
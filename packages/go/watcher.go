@@ -0,0 +1,337 @@
+package oway
+
+import (
+	"container/heap"
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+var errWatcherStarted = errors.New("watcher has already been started")
+
+// WatchEventType identifies the kind of change a Watcher observed
+type WatchEventType string
+
+// Watch event types
+const (
+	// EventStatusChanged is emitted when OrderStatus changes
+	EventStatusChanged WatchEventType = "STATUS_CHANGED"
+	// EventETAChanged is emitted when an estimated pickup/delivery date or the actual delivery date changes
+	EventETAChanged WatchEventType = "ETA_CHANGED"
+	// EventDelivered is emitted once when the order is delivered
+	EventDelivered WatchEventType = "DELIVERED"
+	// EventCancelled is emitted once when the order is cancelled
+	EventCancelled WatchEventType = "CANCELLED"
+)
+
+// WatchEvent is a change observed between two tracking snapshots
+type WatchEvent struct {
+	// Type is the kind of change
+	Type WatchEventType
+
+	// OrderNumber is the shipment the event is about
+	OrderNumber string
+
+	// Previous is the prior snapshot (nil if the order was first observed in a terminal status)
+	Previous *Tracking
+
+	// Current is the latest snapshot
+	Current *Tracking
+}
+
+// WatcherConfig holds configuration for a Watcher
+type WatcherConfig struct {
+	// PollInterval is how often each order is polled (default: 1 minute)
+	PollInterval time.Duration
+
+	// RequestsPerSecond is the TrackShipment budget shared by all orders
+	// (default: 5). Polls are not retried under Config.Retry, so each one is
+	// a single request.
+	RequestsPerSecond float64
+
+	// Handler receives events; if nil, events are delivered on Events()
+	// Handler may be called concurrently for different orders
+	Handler func(WatchEvent)
+
+	// BufferSize is the capacity of the Events() channel (default: 100)
+	BufferSize int
+
+	// OnError is called when polling an order fails
+	OnError func(orderNumber string, err error)
+}
+
+// Watcher polls TrackShipment for many shipments and emits typed events when
+// their status or ETAs change. The first snapshot of each order is the
+// baseline; orders are dropped once they are delivered or cancelled.
+type Watcher struct {
+	client *Client
+	config WatcherConfig
+	events chan WatchEvent
+
+	mu      sync.Mutex
+	orders  map[string]*watchedOrder
+	queue   watchQueue
+	wake    chan struct{}
+	running bool
+}
+
+type watchedOrder struct {
+	apiKey string
+	last   *Tracking
+	due    time.Time
+}
+
+// watchQueue is a min-heap of orders by due time, so an order added or
+// polled later never waits behind one polled recently
+type watchQueue []watchEntry
+
+type watchEntry struct {
+	orderNumber string
+	order       *watchedOrder
+	due         time.Time
+}
+
+func (q watchQueue) Len() int           { return len(q) }
+func (q watchQueue) Less(i, j int) bool { return q[i].due.Before(q[j].due) }
+func (q watchQueue) Swap(i, j int)      { q[i], q[j] = q[j], q[i] }
+func (q *watchQueue) Push(x any)        { *q = append(*q, x.(watchEntry)) }
+
+func (q *watchQueue) Pop() any {
+	old := *q
+	entry := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return entry
+}
+
+// NewWatcher creates a Watcher. Add orders with Watch and start it with Run.
+func (c *Client) NewWatcher(config WatcherConfig) *Watcher {
+	if config.PollInterval <= 0 {
+		config.PollInterval = time.Minute
+	}
+	if config.RequestsPerSecond <= 0 {
+		config.RequestsPerSecond = 5
+	}
+	if config.BufferSize <= 0 {
+		config.BufferSize = 100
+	}
+
+	return &Watcher{
+		client: c,
+		config: config,
+		events: make(chan WatchEvent, config.BufferSize),
+		orders: make(map[string]*watchedOrder),
+		wake:   make(chan struct{}, 1),
+	}
+}
+
+// Watch starts watching an order. companyAPIKey may be empty to use the
+// client's default API key. Watching an order twice updates its API key.
+func (w *Watcher) Watch(orderNumber string, companyAPIKey string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if order, ok := w.orders[orderNumber]; ok {
+		order.apiKey = companyAPIKey
+		return
+	}
+	order := &watchedOrder{apiKey: companyAPIKey, due: time.Now()}
+	w.orders[orderNumber] = order
+	w.enqueue(orderNumber, order)
+}
+
+// enqueue schedules an order at its due time and wakes Run; w.mu must be held
+func (w *Watcher) enqueue(orderNumber string, order *watchedOrder) {
+	heap.Push(&w.queue, watchEntry{orderNumber: orderNumber, order: order, due: order.due})
+	select {
+	case w.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Unwatch stops watching an order
+func (w *Watcher) Unwatch(orderNumber string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	delete(w.orders, orderNumber)
+}
+
+// Len returns the number of orders being watched
+func (w *Watcher) Len() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return len(w.orders)
+}
+
+// Events returns the channel events are delivered on when no Handler is
+// set. It is closed when Run returns.
+func (w *Watcher) Events() <-chan WatchEvent {
+	return w.events
+}
+
+// Run polls watched orders until ctx is done, then returns ctx.Err()
+func (w *Watcher) Run(ctx context.Context) error {
+	w.mu.Lock()
+	if w.running {
+		w.mu.Unlock()
+		return errWatcherStarted
+	}
+	w.running = true
+	w.mu.Unlock()
+
+	var wg sync.WaitGroup
+	defer close(w.events)
+	defer wg.Wait()
+
+	ticker := time.NewTicker(time.Duration(float64(time.Second) / w.config.RequestsPerSecond))
+	defer ticker.Stop()
+
+	for {
+		orderNumber, wait := w.next()
+		if orderNumber == "" {
+			var timer <-chan time.Time
+			if wait > 0 {
+				timer = time.After(wait)
+			}
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-w.wake:
+			case <-timer:
+			}
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w.poll(ctx, orderNumber)
+		}()
+	}
+}
+
+// next pops the earliest due order, or returns how long until one is due
+// (0 if nothing is queued)
+func (w *Watcher) next() (string, time.Duration) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for w.queue.Len() > 0 {
+		entry := w.queue[0]
+		// Skip orders unwatched (or unwatched and watched again) since queued
+		if w.orders[entry.orderNumber] != entry.order {
+			heap.Pop(&w.queue)
+			continue
+		}
+		if wait := time.Until(entry.due); wait > 0 {
+			return "", wait
+		}
+		heap.Pop(&w.queue)
+		return entry.orderNumber, 0
+	}
+	return "", 0
+}
+
+func (w *Watcher) poll(ctx context.Context, orderNumber string) {
+	w.mu.Lock()
+	order, ok := w.orders[orderNumber]
+	if !ok {
+		w.mu.Unlock()
+		return
+	}
+	apiKey := order.apiKey
+	w.mu.Unlock()
+
+	// Not retried: each attempt would spend the shared request budget, and
+	// the order is polled again on its next interval anyway
+	pollCtx := withoutRetries(ctx)
+	if apiKey != "" {
+		pollCtx = WithCompanyAPIKey(pollCtx, apiKey)
+	}
+	tracking, err := w.client.TrackShipment(pollCtx, orderNumber)
+
+	w.mu.Lock()
+	var events []WatchEvent
+	if err == nil {
+		events = diffTracking(orderNumber, order.last, tracking)
+		order.last = tracking
+	}
+	if w.orders[orderNumber] == order {
+		if err == nil && IsTerminalStatus(trackingStatus(tracking)) {
+			delete(w.orders, orderNumber)
+		} else {
+			order.due = time.Now().Add(w.config.PollInterval)
+			w.enqueue(orderNumber, order)
+		}
+	}
+	w.mu.Unlock()
+
+	if err != nil {
+		if ctx.Err() == nil && w.config.OnError != nil {
+			w.config.OnError(orderNumber, err)
+		}
+		return
+	}
+
+	for _, event := range events {
+		if w.config.Handler != nil {
+			w.config.Handler(event)
+			continue
+		}
+		select {
+		case w.events <- event:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// diffTracking returns the events between two snapshots of an order
+func diffTracking(orderNumber string, previous, current *Tracking) []WatchEvent {
+	status := trackingStatus(current)
+	event := func(eventType WatchEventType) WatchEvent {
+		return WatchEvent{Type: eventType, OrderNumber: orderNumber, Previous: previous, Current: current}
+	}
+
+	var events []WatchEvent
+	if previous != nil {
+		if status != trackingStatus(previous) {
+			events = append(events, event(EventStatusChanged))
+		}
+		if !timeEqual(previous.EstimatedPickupDate, current.EstimatedPickupDate) ||
+			!timeEqual(previous.EstimatedDeliveryDate, current.EstimatedDeliveryDate) ||
+			!timeEqual(previous.ActualDeliveryDate, current.ActualDeliveryDate) {
+			events = append(events, event(EventETAChanged))
+		}
+	}
+
+	if previous == nil || status != trackingStatus(previous) {
+		switch status {
+		case TrackingStatusDelivered:
+			events = append(events, event(EventDelivered))
+		case TrackingStatusCancelled:
+			events = append(events, event(EventCancelled))
+		}
+	}
+	return events
+}
+
+func trackingStatus(t *Tracking) TrackingOrderStatus {
+	if t == nil || t.OrderStatus == nil {
+		return ""
+	}
+	return *t.OrderStatus
+}
+
+func timeEqual(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
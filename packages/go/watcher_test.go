@@ -0,0 +1,218 @@
+package oway
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWatcher(t *testing.T) {
+	var mu sync.Mutex
+	polls := map[string]int{}
+	apiKeys := map[string]string{}
+
+	// Each order advances one step per poll and stays on its last snapshot
+	snapshots := map[string][]string{
+		"ABC12": {
+			`{"orderStatus": "CONFIRMED", "estimatedDeliveryDate": "2026-03-02T17:00:00Z"}`,
+			`{"orderStatus": "CONFIRMED", "estimatedDeliveryDate": "2026-03-03T17:00:00Z"}`,
+			`{"orderStatus": "PICKED_UP", "estimatedDeliveryDate": "2026-03-03T17:00:00Z"}`,
+			`{"orderStatus": "DELIVERED", "estimatedDeliveryDate": "2026-03-03T17:00:00Z", "actualDeliveryDate": "2026-03-03T15:00:00Z"}`,
+		},
+		"XYZ99": {
+			`{"orderStatus": "CONFIRMED"}`,
+			`{"orderStatus": "CANCELLED"}`,
+		},
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/v1/auth/token" {
+			w.Write([]byte(`{"accessToken": "test_token", "expiresIn": 3600}`))
+			return
+		}
+
+		var orderNumber string
+		fmt.Sscanf(r.URL.Path, "/v1/shipper/shipment/%5s/tracking", &orderNumber)
+		mu.Lock()
+		n := polls[orderNumber]
+		polls[orderNumber]++
+		apiKeys[orderNumber] = r.Header.Get("x-oway-api-key")
+		mu.Unlock()
+
+		sequence := snapshots[orderNumber]
+		w.Write([]byte(sequence[min(n, len(sequence)-1)]))
+	}))
+	defer server.Close()
+
+	client, err := New(Config{
+		ClientID:     "client_test",
+		ClientSecret: "secret_test",
+		APIKey:       "oway_sk_default",
+		BaseURL:      server.URL,
+		TokenURL:     server.URL + "/v1/auth/token",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	watcher := client.NewWatcher(WatcherConfig{
+		PollInterval:      time.Millisecond,
+		RequestsPerSecond: 1000,
+		OnError: func(orderNumber string, err error) {
+			t.Errorf("Poll failed for %s: %v", orderNumber, err)
+		},
+	})
+	watcher.Watch("ABC12", "oway_sk_acme")
+	watcher.Watch("XYZ99", "")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	go watcher.Run(ctx)
+
+	got := map[string][]WatchEventType{}
+	for event := range watcher.Events() {
+		got[event.OrderNumber] = append(got[event.OrderNumber], event.Type)
+		if watcher.Len() == 0 && len(got["ABC12"]) == 5 && len(got["XYZ99"]) == 2 {
+			cancel()
+		}
+	}
+
+	want := map[string][]WatchEventType{
+		"ABC12": {EventETAChanged, EventStatusChanged, EventStatusChanged, EventETAChanged, EventDelivered},
+		"XYZ99": {EventStatusChanged, EventCancelled},
+	}
+	for orderNumber, events := range want {
+		if fmt.Sprint(got[orderNumber]) != fmt.Sprint(events) {
+			t.Errorf("%s: expected events %v, got %v", orderNumber, events, got[orderNumber])
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if apiKeys["ABC12"] != "oway_sk_acme" || apiKeys["XYZ99"] != "oway_sk_default" {
+		t.Errorf("Unexpected API keys %v", apiKeys)
+	}
+	if polls["ABC12"] != 4 || polls["XYZ99"] != 2 {
+		t.Errorf("Expected orders to be dropped after terminal status, got polls %v", polls)
+	}
+}
+
+func TestWatcherScheduling(t *testing.T) {
+	polled := make(chan string, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/v1/auth/token" {
+			w.Write([]byte(`{"accessToken": "test_token", "expiresIn": 3600}`))
+			return
+		}
+		var orderNumber string
+		fmt.Sscanf(r.URL.Path, "/v1/shipper/shipment/%5s/tracking", &orderNumber)
+		polled <- orderNumber
+		w.Write([]byte(`{"orderStatus": "CONFIRMED"}`))
+	}))
+	defer server.Close()
+
+	client, err := New(Config{
+		ClientID:     "client_test",
+		ClientSecret: "secret_test",
+		BaseURL:      server.URL,
+		TokenURL:     server.URL + "/v1/auth/token",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	watcher := client.NewWatcher(WatcherConfig{PollInterval: time.Hour, RequestsPerSecond: 1000})
+	watcher.Watch("ABC12", "")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- watcher.Run(ctx) }()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	if got := <-polled; got != "ABC12" {
+		t.Fatalf("Expected ABC12 to be polled first, got %s", got)
+	}
+	// Wait for ABC12 to be rescheduled an hour out
+	for {
+		watcher.mu.Lock()
+		queued := len(watcher.queue)
+		watcher.mu.Unlock()
+		if queued == 1 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	t.Run("should poll an order watched later before the head is due again", func(t *testing.T) {
+		watcher.Watch("XYZ99", "")
+		select {
+		case got := <-polled:
+			if got != "XYZ99" {
+				t.Errorf("Expected XYZ99, got %s", got)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatal("Expected XYZ99 to be polled without waiting for ABC12's interval")
+		}
+	})
+}
+
+func TestWatcherRetries(t *testing.T) {
+	var trackCalls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/v1/auth/token" {
+			w.Write([]byte(`{"accessToken": "test_token", "expiresIn": 3600}`))
+			return
+		}
+		trackCalls.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	client, err := New(Config{
+		ClientID:     "client_test",
+		ClientSecret: "secret_test",
+		BaseURL:      server.URL,
+		TokenURL:     server.URL + "/v1/auth/token",
+		Retry:        &RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	failed := make(chan error, 1)
+	watcher := client.NewWatcher(WatcherConfig{
+		PollInterval: time.Hour,
+		OnError:      func(_ string, err error) { failed <- err },
+	})
+	watcher.Watch("ABC12", "")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- watcher.Run(ctx) }()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	t.Run("should poll once per tick without retrying", func(t *testing.T) {
+		select {
+		case <-failed:
+		case <-time.After(2 * time.Second):
+			t.Fatal("Expected the poll to fail")
+		}
+		if trackCalls.Load() != 1 {
+			t.Errorf("Expected 1 request, got %d", trackCalls.Load())
+		}
+	})
+}